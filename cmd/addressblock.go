@@ -19,6 +19,7 @@ limitations under the License.
 import (
 	"errors"
 	"fmt"
+	"net"
 	"sort"
 	"strconv"
 
@@ -100,6 +101,18 @@ var addressblockCreateCmd = &cobra.Command{
 			o.Name = &detailName
 		}
 
+		if detailCIDR != "" {
+			if detailSubnet != "" || detailPrefix != 0 {
+				return fmt.Errorf("cidr can not be used with subnet or prefix")
+			}
+			subnet, prefix, err := parseCIDR(detailCIDR)
+			if err != nil {
+				return err
+			}
+			detailSubnet = subnet
+			detailPrefix = prefix
+		}
+
 		if detailSubnet != "" {
 			o.Subnet = &detailSubnet
 		}
@@ -108,6 +121,32 @@ var addressblockCreateCmd = &cobra.Command{
 			o.Prefix = &detailPrefix
 		}
 
+		var network *net.IPNet
+		if detailSubnet != "" && detailPrefix != 0 {
+			var err error
+			network, err = blockNetwork(o)
+			if err != nil {
+				return err
+			}
+			if !network.IP.Equal(net.ParseIP(detailSubnet)) {
+				return fmt.Errorf("subnet '%s' is not aligned to the prefix %d, did you mean '%s'", detailSubnet, detailPrefix, network.String())
+			}
+		}
+
+		if detailGatewayIP != "" {
+			if detailGatewayOffset != 0 {
+				return fmt.Errorf("gateway-ip can not be used with gateway")
+			}
+			if network == nil {
+				return fmt.Errorf("subnet and prefix or cidr are required to use gateway-ip")
+			}
+			offset, err := gatewayOffset(network, detailGatewayIP)
+			if err != nil {
+				return err
+			}
+			detailGatewayOffset = offset
+		}
+
 		if detailGatewayOffset != 0 {
 			o.GatewayOffset = &detailGatewayOffset
 		}
@@ -120,6 +159,12 @@ var addressblockCreateCmd = &cobra.Command{
 				return err
 			}
 			o.Site = cinp.StringAddr(r.GetURI())
+
+			if network != nil {
+				if err := checkBlockOverlap(ctx, r.GetURI(), network); err != nil {
+					return err
+				}
+			}
 		}
 
 		err := o.Create(ctx)
@@ -320,7 +365,7 @@ var addressblockAllocationCmd = &cobra.Command{
 			return offsetI < offsetJ
 		})
 
-		outputList(rl, []string{"Id", "Offset", "Ip Address", "Type"}, "{{.GetURI | extractID}}	{{.Offset}}	{{.IPAddress}}	{{.Type}}\n")
+		outputList(rl, []string{"Id", "Offset", "Ip Address", "Type"}, "{{.GetURI | extractID}}	{{.Offset}}	{{.IPAddress | formatIP}}	{{.Type}}\n")
 
 		return nil
 	},
@@ -336,10 +381,6 @@ var addressblockReserveCmd = &cobra.Command{
 			return err
 		}
 
-		if detailOffset == 0 {
-			return fmt.Errorf("offset required")
-		}
-
 		if detailReason == "" {
 			return fmt.Errorf("reason Required")
 		}
@@ -351,17 +392,23 @@ var addressblockReserveCmd = &cobra.Command{
 			return err
 		}
 
-		o := contractorClient.UtilitiesReservedAddressNew()
-		o.AddressBlock = cinp.StringAddr(r.GetURI())
-		o.Offset = &detailOffset
-		o.Reason = &detailReason
-
-		err = o.Create(ctx)
+		offsetList, err := targetOffsets(r)
 		if err != nil {
 			return err
 		}
 
-		outputDetail(o, `Id:             {{.GetURI | extractID}}
+		if !rangeRequested() {
+			o := contractorClient.UtilitiesReservedAddressNew()
+			o.AddressBlock = cinp.StringAddr(r.GetURI())
+			o.Offset = &detailOffset
+			o.Reason = &detailReason
+
+			err = o.Create(ctx)
+			if err != nil {
+				return err
+			}
+
+			outputDetail(o, `Id:             {{.GetURI | extractID}}
 AddressBlock:   {{.AddressBlock | extractID}}
 Offset:         {{.Offset}}
 Reason:         {{.Reason}}
@@ -369,7 +416,38 @@ Updated:        {{.Updated}}
 Created:        {{.Created}}
 `)
 
-		return nil
+			return nil
+		}
+
+		network, err := blockNetwork(r)
+		if err != nil {
+			return err
+		}
+		allocationMap, err := blockAllocations(ctx, r, network)
+		if err != nil {
+			return err
+		}
+		inUse := blockOffsetUse(r, allocationMap)
+
+		skipped := map[int]string{}
+		todoList := []int{}
+		for _, offset := range offsetList {
+			if use, ok := inUse[offset]; ok {
+				skipped[offset] = "in use as " + use
+				continue
+			}
+			todoList = append(todoList, offset)
+		}
+
+		failed := runOffsetOperation(todoList, func(offset int) error {
+			o := contractorClient.UtilitiesReservedAddressNew()
+			o.AddressBlock = cinp.StringAddr(r.GetURI())
+			o.Offset = &offset
+			o.Reason = &detailReason
+			return o.Create(ctx)
+		})
+
+		return printOffsetSummary("Reserved", len(offsetList), skipped, failed)
 	},
 }
 
@@ -383,28 +461,50 @@ var addressblockDeReserveCmd = &cobra.Command{
 			return err
 		}
 
-		if detailOffset == 0 {
-			return fmt.Errorf("offset required")
-		}
-
 		ctx := cmd.Context()
 
 		o, err := contractorClient.UtilitiesAddressBlockGet(ctx, addressblockID)
 		if err != nil {
 			return err
 		}
+
+		offsetList, err := targetOffsets(o)
+		if err != nil {
+			return err
+		}
+
+		reservedMap := map[int]*contractor.UtilitiesReservedAddress{}
 		vchan, err := contractorClient.UtilitiesReservedAddressList(ctx, "address_block", map[string]interface{}{"address_block": o.GetURI()})
 		if err != nil {
 			return err
 		}
 		for v := range vchan {
-			if *v.Offset == detailOffset {
-				v.Delete(ctx)
-				return nil
+			reservedMap[*v.Offset] = v
+		}
+
+		if !rangeRequested() {
+			v, ok := reservedMap[detailOffset]
+			if !ok {
+				return fmt.Errorf("offset not found")
 			}
+			return v.Delete(ctx)
 		}
 
-		return fmt.Errorf("offset not found")
+		skipped := map[int]string{}
+		todoList := []int{}
+		for _, offset := range offsetList {
+			if _, ok := reservedMap[offset]; !ok {
+				skipped[offset] = "not reserved"
+				continue
+			}
+			todoList = append(todoList, offset)
+		}
+
+		failed := runOffsetOperation(todoList, func(offset int) error {
+			return reservedMap[offset].Delete(ctx)
+		})
+
+		return printOffsetSummary("Dereserved", len(offsetList), skipped, failed)
 	},
 }
 
@@ -418,10 +518,6 @@ var addressblockDynamicCmd = &cobra.Command{
 			return err
 		}
 
-		if detailOffset == 0 {
-			return fmt.Errorf("offset required")
-		}
-
 		ctx := cmd.Context()
 
 		r, err := contractorClient.UtilitiesAddressBlockGet(ctx, addressblockID)
@@ -429,30 +525,70 @@ var addressblockDynamicCmd = &cobra.Command{
 			return err
 		}
 
-		o := contractorClient.UtilitiesDynamicAddressNew()
-		o.AddressBlock = cinp.StringAddr(r.GetURI())
-		o.Offset = &detailOffset
+		offsetList, err := targetOffsets(r)
+		if err != nil {
+			return err
+		}
+
+		var pxe *string
 		if detailPXE != "" {
 			r2, err := contractorClient.BlueprintPXEGet(ctx, detailPXE)
 			if err != nil {
 				return err
 			}
-			o.Pxe = cinp.StringAddr(r2.GetURI())
+			pxe = cinp.StringAddr(r2.GetURI())
 		}
 
-		err = o.Create(ctx)
-		if err != nil {
-			return err
-		}
+		if !rangeRequested() {
+			o := contractorClient.UtilitiesDynamicAddressNew()
+			o.AddressBlock = cinp.StringAddr(r.GetURI())
+			o.Offset = &detailOffset
+			o.Pxe = pxe
+
+			err = o.Create(ctx)
+			if err != nil {
+				return err
+			}
 
-		outputDetail(o, `Id:           {{.GetURI | extractID}}
+			outputDetail(o, `Id:           {{.GetURI | extractID}}
 AddressBlock: {{.AddressBlock | extractID}}
 Offset:       {{.Offset}}
 Updated:      {{.Updated}}
 Created:      {{.Created}}
 `)
 
-		return nil
+			return nil
+		}
+
+		network, err := blockNetwork(r)
+		if err != nil {
+			return err
+		}
+		allocationMap, err := blockAllocations(ctx, r, network)
+		if err != nil {
+			return err
+		}
+		inUse := blockOffsetUse(r, allocationMap)
+
+		skipped := map[int]string{}
+		todoList := []int{}
+		for _, offset := range offsetList {
+			if use, ok := inUse[offset]; ok {
+				skipped[offset] = "in use as " + use
+				continue
+			}
+			todoList = append(todoList, offset)
+		}
+
+		failed := runOffsetOperation(todoList, func(offset int) error {
+			o := contractorClient.UtilitiesDynamicAddressNew()
+			o.AddressBlock = cinp.StringAddr(r.GetURI())
+			o.Offset = &offset
+			o.Pxe = pxe
+			return o.Create(ctx)
+		})
+
+		return printOffsetSummary("Assigned", len(offsetList), skipped, failed)
 	},
 }
 
@@ -466,28 +602,50 @@ var addressblockDeDynamicCmd = &cobra.Command{
 			return err
 		}
 
-		if detailOffset == 0 {
-			return fmt.Errorf("offset required")
-		}
-
 		ctx := cmd.Context()
 
 		o, err := contractorClient.UtilitiesAddressBlockGet(ctx, addressblockID)
 		if err != nil {
 			return err
 		}
+
+		offsetList, err := targetOffsets(o)
+		if err != nil {
+			return err
+		}
+
+		dynamicMap := map[int]*contractor.UtilitiesDynamicAddress{}
 		vchan, err := contractorClient.UtilitiesDynamicAddressList(ctx, "address_block", map[string]interface{}{"address_block": o.GetURI()})
 		if err != nil {
 			return err
 		}
 		for v := range vchan {
-			if *v.Offset == detailOffset {
-				v.Delete(ctx)
-				return nil
+			dynamicMap[*v.Offset] = v
+		}
+
+		if !rangeRequested() {
+			v, ok := dynamicMap[detailOffset]
+			if !ok {
+				return fmt.Errorf("offset not found")
+			}
+			return v.Delete(ctx)
+		}
+
+		skipped := map[int]string{}
+		todoList := []int{}
+		for _, offset := range offsetList {
+			if _, ok := dynamicMap[offset]; !ok {
+				skipped[offset] = "not dynamic"
+				continue
 			}
+			todoList = append(todoList, offset)
 		}
 
-		return fmt.Errorf("offset not found")
+		failed := runOffsetOperation(todoList, func(offset int) error {
+			return dynamicMap[offset].Delete(ctx)
+		})
+
+		return printOffsetSummary("De-assigned", len(offsetList), skipped, failed)
 	},
 }
 
@@ -497,6 +655,8 @@ func init() {
 	addressblockCreateCmd.Flags().StringVarP(&detailSubnet, "subnet", "u", "", "Subnet of the New AddressBlock")
 	addressblockCreateCmd.Flags().IntVarP(&detailPrefix, "prefix", "p", 0, "Prefix of the New AddressBlock")
 	addressblockCreateCmd.Flags().IntVarP(&detailGatewayOffset, "gateway", "g", 0, "Gateway Offset of the New AddressBlock")
+	addressblockCreateCmd.Flags().StringVarP(&detailCIDR, "cidr", "c", "", "Subnet and Prefix of the New AddressBlock in CIDR form, ie: 10.1.0.0/22 or 2001:db8::/64")
+	addressblockCreateCmd.Flags().StringVarP(&detailGatewayIP, "gateway-ip", "", "", "Gateway Ip of the New AddressBlock, the offset is calculated")
 
 	addressblockUpdateCmd.Flags().StringVarP(&detailName, "name", "n", "", "Update the Name of the AddressBlock")
 	addressblockUpdateCmd.Flags().StringVarP(&detailSite, "site", "s", "", "Update the Site of the AddressBlock")
@@ -505,14 +665,22 @@ func init() {
 	addressblockUpdateCmd.Flags().IntVarP(&detailGatewayOffset, "gateway", "g", 0, "Update the Gateway Offset of the AddressBlock")
 
 	addressblockReserveCmd.Flags().IntVarP(&detailOffset, "offset", "o", 0, "Offset for the New Reservation")
+	addressblockReserveCmd.Flags().StringVarP(&detailOffsets, "offsets", "", "", "Offsets to Reserve, ie: 100-199,210")
+	addressblockReserveCmd.Flags().StringVarP(&detailRange, "range", "", "", "Ip Range to Reserve, ie: 10.0.0.100-10.0.0.199")
 	addressblockReserveCmd.Flags().StringVarP(&detailReason, "reason", "r", "", "Reason for the New Reservation")
 
 	addressblockDeReserveCmd.Flags().IntVarP(&detailOffset, "offset", "o", 0, "Offset of the Reservation to Remove")
+	addressblockDeReserveCmd.Flags().StringVarP(&detailOffsets, "offsets", "", "", "Offsets to Dereserve, ie: 100-199,210")
+	addressblockDeReserveCmd.Flags().StringVarP(&detailRange, "range", "", "", "Ip Range to Dereserve, ie: 10.0.0.100-10.0.0.199")
 
 	addressblockDynamicCmd.Flags().IntVarP(&detailOffset, "offset", "o", 0, "Offset for the New Dynamic Ip")
+	addressblockDynamicCmd.Flags().StringVarP(&detailOffsets, "offsets", "", "", "Offsets to Assign as Dynamic, ie: 100-199,210")
+	addressblockDynamicCmd.Flags().StringVarP(&detailRange, "range", "", "", "Ip Range to Assign as Dynamic, ie: 10.0.0.100-10.0.0.199")
 	addressblockDynamicCmd.Flags().StringVarP(&detailPXE, "pxe", "p", "", "PXE for the New Dynamic Ip")
 
 	addressblockDeDynamicCmd.Flags().IntVarP(&detailOffset, "offset", "o", 0, "Offset of the Dynamic Ip to Remove")
+	addressblockDeDynamicCmd.Flags().StringVarP(&detailOffsets, "offsets", "", "", "Offsets to De-assign as Dynamic, ie: 100-199,210")
+	addressblockDeDynamicCmd.Flags().StringVarP(&detailRange, "range", "", "", "Ip Range to De-assign as Dynamic, ie: 10.0.0.100-10.0.0.199")
 
 	rootCmd.AddCommand(addressblockCmd)
 	addressblockCmd.AddCommand(addressblockListCmd, addressblockGetCmd, addressblockCreateCmd, addressblockUpdateCmd, addressblockDeleteCmd, addressblockUsageCmd, addressblockAllocationCmd, addressblockReserveCmd, addressblockDeReserveCmd, addressblockDynamicCmd, addressblockDeDynamicCmd)
@@ -0,0 +1,196 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	cinp "github.com/cinp/go"
+	"github.com/spf13/cobra"
+	contractor "github.com/t3kton/contractor_goclient"
+)
+
+const capacityWorkers = 8
+
+type blockCapacity struct {
+	cinp.BaseObject
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Site        string  `json:"site"`
+	Subnet      string  `json:"subnet"`
+	Networks    string  `json:"networks"`
+	Total       int     `json:"total"`
+	Used        int     `json:"used"`
+	Free        int     `json:"free"`
+	Utilization float64 `json:"utilization"`
+	Status      string  `json:"status"`
+}
+
+func usageCount(usage map[string]interface{}, name string) int {
+	value, _ := toFloat(usage[name])
+	return int(value)
+}
+
+var addressblockCapacityCmd = &cobra.Command{
+	Use:   "capacity",
+	Short: "Report the utilization of AddressBlocks, exits non-zero if any are over the critical threshold",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if detailWarn > detailCrit {
+			return fmt.Errorf("warn threshold must not be more than the crit threshold")
+		}
+
+		ctx := cmd.Context()
+
+		filterName := ""
+		filterValues := map[string]interface{}{}
+		if detailSite != "" {
+			r, err := contractorClient.SiteSiteGet(ctx, detailSite)
+			if err != nil {
+				return err
+			}
+			filterName = "site"
+			filterValues["site"] = r.GetURI()
+		}
+
+		blockList := []*contractor.UtilitiesAddressBlock{}
+		bchan, err := contractorClient.UtilitiesAddressBlockList(ctx, filterName, filterValues)
+		if err != nil {
+			return err
+		}
+		for v := range bchan {
+			blockList = append(blockList, v)
+		}
+
+		networkNames := map[string]string{}
+		nchan, err := contractorClient.UtilitiesNetworkList(ctx, filterName, filterValues)
+		if err != nil {
+			return err
+		}
+		for v := range nchan {
+			networkNames[v.GetURI()] = stringValue(v.Name)
+		}
+
+		blockNetworks := map[string][]string{}
+		nbchan, err := contractorClient.UtilitiesNetworkAddressBlockList(ctx, "", map[string]interface{}{})
+		if err != nil {
+			return err
+		}
+		for v := range nbchan {
+			name, ok := networkNames[stringValue(v.Network)]
+			if !ok {
+				continue
+			}
+			if v.Vlan != nil && *v.Vlan != 0 {
+				name = fmt.Sprintf("%s(vlan %d)", name, *v.Vlan)
+			}
+			block := stringValue(v.AddressBlock)
+			blockNetworks[block] = append(blockNetworks[block], name)
+		}
+
+		resultList := make([]*blockCapacity, len(blockList))
+		errorList := make([]error, len(blockList))
+		var wg sync.WaitGroup
+		queue := make(chan int)
+		for i := 0; i < capacityWorkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for index := range queue {
+					block := blockList[index]
+					usage, err := block.CallUsage(ctx)
+					if err != nil {
+						errorList[index] = fmt.Errorf("error getting the usage of AddressBlock %s: %s", extractID(block.GetURI()), err)
+						continue
+					}
+
+					networks := blockNetworks[block.GetURI()]
+					sort.Strings(networks)
+
+					result := &blockCapacity{
+						ID:       extractID(block.GetURI()),
+						Name:     stringValue(block.Name),
+						Site:     extractID(stringValue(block.Site)),
+						Networks: strings.Join(networks, ", "),
+						Total:    usageCount(usage, "total"),
+						Used:     usageCount(usage, "static") + usageCount(usage, "reserved") + usageCount(usage, "dynamic"),
+					}
+					if block.Subnet != nil && block.Prefix != nil {
+						result.Subnet = fmt.Sprintf("%s/%d", *block.Subnet, *block.Prefix)
+					}
+					result.Free = result.Total - result.Used
+					if result.Total > 0 {
+						result.Utilization = float64(result.Used) * 100 / float64(result.Total)
+					}
+					switch {
+					case result.Utilization >= float64(detailCrit):
+						result.Status = "CRIT"
+					case result.Utilization >= float64(detailWarn):
+						result.Status = "WARN"
+					default:
+						result.Status = "OK"
+					}
+					resultList[index] = result
+				}
+			}()
+		}
+		for i := range blockList {
+			queue <- i
+		}
+		close(queue)
+		wg.Wait()
+
+		for _, err := range errorList {
+			if err != nil {
+				return err
+			}
+		}
+
+		sort.SliceStable(resultList, func(i, j int) bool {
+			if resultList[i].Utilization != resultList[j].Utilization {
+				return resultList[i].Utilization > resultList[j].Utilization
+			}
+			return resultList[i].Free < resultList[j].Free
+		})
+
+		critical := 0
+		rl := []cinp.Object{}
+		for _, v := range resultList {
+			rl = append(rl, v)
+			if v.Status == "CRIT" {
+				critical++
+			}
+		}
+		outputList(rl, []string{"Id", "Name", "Site", "Subnet", "Networks", "Total", "Used", "Free", "Utilization", "Status"}, "{{.ID}}	{{.Name}}	{{.Site}}	{{.Subnet}}	{{.Networks}}	{{.Total}}	{{.Used}}	{{.Free}}	{{printf \"%.1f%%\" .Utilization}}	{{.Status}}\n")
+
+		if critical > 0 {
+			return fmt.Errorf("%d AddressBlock(s) at or over the critical threshold of %d%%", critical, detailCrit)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	addressblockCapacityCmd.Flags().StringVarP(&detailSite, "site", "s", "", "Only report on the AddressBlocks of Site")
+	addressblockCapacityCmd.Flags().IntVarP(&detailWarn, "warn", "w", 80, "Utilization percentage to warn at")
+	addressblockCapacityCmd.Flags().IntVarP(&detailCrit, "crit", "c", 95, "Utilization percentage that is critical, exits non-zero if any AddressBlock reaches it")
+
+	addressblockCmd.AddCommand(addressblockCapacityCmd)
+}
@@ -0,0 +1,246 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	cinp "github.com/cinp/go"
+	"github.com/spf13/cobra"
+	contractor "github.com/t3kton/contractor_goclient"
+)
+
+const addressblockMapMaxSize = 4096
+const addressblockMapWidth = 16
+
+type addressAllocation struct {
+	cinp.BaseObject
+	Offset    int    `json:"offset"`
+	EndOffset int    `json:"end_offset"`
+	IPAddress string `json:"ip_address"`
+	Type      string `json:"type"`
+	Detail    string `json:"detail"`
+}
+
+type addressRange struct {
+	cinp.BaseObject
+	Offset    int    `json:"offset"`
+	EndOffset int    `json:"end_offset"`
+	IPAddress string `json:"ip_address"`
+	EndIP     string `json:"end_ip_address"`
+	Count     int    `json:"count"`
+}
+
+// networkedHostname returns the hostname of the Networked at uri, lookups are cached in cache
+func networkedHostname(ctx context.Context, uri string, cache map[string]string) string {
+	if uri == "" {
+		return ""
+	}
+	if hostname, ok := cache[uri]; ok {
+		return hostname
+	}
+	hostname := ""
+	if id, err := strconv.Atoi(extractID(uri)); err == nil {
+		if r, err := contractorClient.UtilitiesNetworkedGet(ctx, id); err == nil {
+			hostname = stringValue(r.Hostname)
+		}
+	}
+	cache[uri] = hostname
+	return hostname
+}
+
+// blockAllocations returns the static, reserved and dynamic addresses in block by offset, consecutive
+// dynamic addresses with the same PXE are not combined
+func blockAllocations(ctx context.Context, block *contractor.UtilitiesAddressBlock, network *net.IPNet) (map[int]*addressAllocation, error) {
+	result := map[int]*addressAllocation{}
+	hostnameCache := map[string]string{}
+
+	vchan, err := contractorClient.UtilitiesAddressList(ctx, "address_block", map[string]interface{}{"address_block": block.GetURI()})
+	if err != nil {
+		return nil, err
+	}
+	for v := range vchan {
+		detail := networkedHostname(ctx, stringValue(v.Networked), hostnameCache)
+		if v.InterfaceName != nil && *v.InterfaceName != "" {
+			detail += " (" + *v.InterfaceName + ")"
+		}
+		result[*v.Offset] = &addressAllocation{Offset: *v.Offset, EndOffset: *v.Offset, IPAddress: offsetIP(network, *v.Offset).String(), Type: "Static", Detail: detail}
+	}
+
+	vchan2, err := contractorClient.UtilitiesReservedAddressList(ctx, "address_block", map[string]interface{}{"address_block": block.GetURI()})
+	if err != nil {
+		return nil, err
+	}
+	for v := range vchan2 {
+		result[*v.Offset] = &addressAllocation{Offset: *v.Offset, EndOffset: *v.Offset, IPAddress: offsetIP(network, *v.Offset).String(), Type: "Reserved", Detail: stringValue(v.Reason)}
+	}
+
+	vchan3, err := contractorClient.UtilitiesDynamicAddressList(ctx, "address_block", map[string]interface{}{"address_block": block.GetURI()})
+	if err != nil {
+		return nil, err
+	}
+	for v := range vchan3 {
+		detail := ""
+		if v.Pxe != nil && *v.Pxe != "" {
+			detail = "pxe: " + extractID(*v.Pxe)
+		}
+		result[*v.Offset] = &addressAllocation{Offset: *v.Offset, EndOffset: *v.Offset, IPAddress: offsetIP(network, *v.Offset).String(), Type: "Dynamic", Detail: detail}
+	}
+
+	return result, nil
+}
+
+// freeRanges returns the ranges of usable offsets in network that are not allocated or the gateway
+func freeRanges(network *net.IPNet, allocationMap map[int]*addressAllocation, gatewayOffset int) []*addressRange {
+	result := []*addressRange{}
+	first, last := usableOffsets(network)
+
+	var current *addressRange
+	for offset := int(first.Int64()); offset <= int(last.Int64()); offset++ {
+		_, used := allocationMap[offset]
+		if used || offset == gatewayOffset {
+			current = nil
+			continue
+		}
+		if current == nil {
+			current = &addressRange{Offset: offset, IPAddress: offsetIP(network, offset).String()}
+			result = append(result, current)
+		}
+		current.EndOffset = offset
+		current.EndIP = offsetIP(network, offset).String()
+		current.Count++
+	}
+
+	return result
+}
+
+var addressblockMapCmd = &cobra.Command{
+	Use:   "map",
+	Short: "Display a map of every offset in an AddressBlock",
+	Args:  addressblockArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addressblockID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		o, err := contractorClient.UtilitiesAddressBlockGet(ctx, addressblockID)
+		if err != nil {
+			return err
+		}
+
+		network, err := blockNetwork(o)
+		if err != nil {
+			return err
+		}
+		size := networkSize(network)
+		if size.Cmp(big.NewInt(addressblockMapMaxSize)) > 0 {
+			return fmt.Errorf("address block has %s addresses, too many to map, the maximum is %d", size.String(), addressblockMapMaxSize)
+		}
+
+		allocationMap, err := blockAllocations(ctx, o, network)
+		if err != nil {
+			return err
+		}
+
+		gatewayOffset := -1
+		if o.GatewayOffset != nil {
+			gatewayOffset = *o.GatewayOffset
+		}
+
+		offsetList := []int{}
+		for offset := range allocationMap {
+			offsetList = append(offsetList, offset)
+		}
+		sort.Ints(offsetList)
+
+		// combine consecutive dynamic addresses with the same detail into ranges
+		allocationList := []*addressAllocation{}
+		for _, offset := range offsetList {
+			allocation := allocationMap[offset]
+			if len(allocationList) > 0 {
+				prev := allocationList[len(allocationList)-1]
+				if allocation.Type == "Dynamic" && prev.Type == "Dynamic" && prev.Detail == allocation.Detail && prev.EndOffset+1 == offset {
+					prev.EndOffset = offset
+					continue
+				}
+			}
+			item := *allocation
+			allocationList = append(allocationList, &item)
+		}
+
+		free := freeRanges(network, allocationMap, gatewayOffset)
+
+		if asJSON {
+			outputDetail(map[string]interface{}{"gateway_offset": gatewayOffset, "allocations": allocationList, "free": free}, "")
+			return nil
+		}
+
+		first, last := usableOffsets(network)
+		count := int(size.Int64())
+		for row := 0; row < count; row += addressblockMapWidth {
+			cellList := []string{}
+			for offset := row; offset < row+addressblockMapWidth && offset < count; offset++ {
+				cell := "."
+				if allocation, ok := allocationMap[offset]; ok {
+					cell = allocation.Type[:1]
+				} else if offset == gatewayOffset {
+					cell = "G"
+				} else if int64(offset) < first.Int64() || int64(offset) > last.Int64() {
+					cell = "-"
+				}
+				cellList = append(cellList, cell)
+			}
+			fmt.Printf("%-40s %s\n", offsetIP(network, row).String(), strings.Join(cellList, " "))
+		}
+		fmt.Println("\nG: Gateway  S: Static  R: Reserved  D: Dynamic  .: Free  -: Network/Broadcast")
+
+		if gatewayOffset >= 0 {
+			fmt.Printf("\nGateway: %s (offset %d)\n", offsetIP(network, gatewayOffset).String(), gatewayOffset)
+		}
+
+		fmt.Println()
+		rl := []cinp.Object{}
+		for _, v := range allocationList {
+			rl = append(rl, v)
+		}
+		outputList(rl, []string{"Offset", "End Offset", "Ip Address", "Type", "Detail"}, "{{.Offset}}	{{.EndOffset}}	{{.IPAddress}}	{{.Type}}	{{.Detail}}\n")
+
+		fmt.Println("Free:")
+		rl = []cinp.Object{}
+		total := 0
+		for _, v := range free {
+			rl = append(rl, v)
+			total += v.Count
+		}
+		outputList(rl, []string{"Offset", "End Offset", "Ip Address", "End Ip Address", "Count"}, "{{.Offset}}	{{.EndOffset}}	{{.IPAddress}}	{{.EndIP}}	{{.Count}}\n")
+		fmt.Printf("%d free addresses in %d ranges\n", total, len(free))
+
+		return nil
+	},
+}
+
+func init() {
+	addressblockCmd.AddCommand(addressblockMapCmd)
+}
@@ -0,0 +1,236 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	contractor "github.com/t3kton/contractor_goclient"
+)
+
+const offsetWorkers = 8
+const offsetMaxCount = addressblockMapMaxSize
+
+// parseOffsetList parses a list of offsets and offset ranges, ie: "100-199,210", offsets must be less than size
+func parseOffsetList(value string, size int) ([]int, error) {
+	result := []int{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "-", 2)
+		start, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset '%s'", item)
+		}
+		end := start
+		if len(parts) == 2 {
+			end, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid offset range '%s'", item)
+			}
+		}
+		if end < start {
+			return nil, fmt.Errorf("offset range '%s' ends before it starts", item)
+		}
+		if end >= size {
+			return nil, fmt.Errorf("offset '%s' is outside of the address block, which has %d addresses", item, size)
+		}
+		if end-start >= offsetMaxCount {
+			return nil, fmt.Errorf("offset range '%s' has too many offsets, the maximum is %d", item, offsetMaxCount)
+		}
+		for offset := start; offset <= end; offset++ {
+			result = append(result, offset)
+		}
+	}
+	return result, nil
+}
+
+// parseIPRange parses an ip range, ie: "10.0.0.100-10.0.0.199" into offsets of network
+func parseIPRange(value string, network *net.IPNet) ([]int, error) {
+	parts := strings.SplitN(value, "-", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid ip range '%s', expected <first ip>-<last ip>", value)
+	}
+
+	offsets := [2]int{}
+	for i, part := range parts {
+		ip := net.ParseIP(strings.TrimSpace(part))
+		if ip == nil {
+			return nil, fmt.Errorf("invalid ip address '%s'", part)
+		}
+		offset, ok := ipOffset(network, ip)
+		if !ok {
+			return nil, fmt.Errorf("ip address '%s' is not in %s", part, network.String())
+		}
+		if !offset.IsInt64() || offset.Int64() > 1<<24 {
+			return nil, fmt.Errorf("ip address '%s' offset is too large", part)
+		}
+		offsets[i] = int(offset.Int64())
+	}
+	if offsets[1] < offsets[0] {
+		return nil, fmt.Errorf("ip range '%s' ends before it starts", value)
+	}
+	if offsets[1]-offsets[0] >= offsetMaxCount {
+		return nil, fmt.Errorf("ip range '%s' has too many addresses, the maximum is %d", value, offsetMaxCount)
+	}
+
+	result := []int{}
+	for offset := offsets[0]; offset <= offsets[1]; offset++ {
+		result = append(result, offset)
+	}
+	return result, nil
+}
+
+// targetOffsets returns the offsets selected by --offset, --offsets and --range, sorted and without duplicates,
+// the network and broadcast addresses are rejected
+func targetOffsets(block *contractor.UtilitiesAddressBlock) ([]int, error) {
+	network, err := blockNetwork(block)
+	if err != nil {
+		return nil, err
+	}
+
+	offsetList := []int{}
+	if detailOffset != 0 {
+		offsetList = append(offsetList, detailOffset)
+	}
+
+	if detailOffsets != "" {
+		// same cap as parseIPRange, so huge (ie: IPv6) blocks do not expand into billions of offsets
+		size := 1<<24 + 1
+		if blockSize := networkSize(network); blockSize.IsInt64() && blockSize.Int64() < int64(size) {
+			size = int(blockSize.Int64())
+		}
+		values, err := parseOffsetList(detailOffsets, size)
+		if err != nil {
+			return nil, err
+		}
+		offsetList = append(offsetList, values...)
+	}
+
+	if detailRange != "" {
+		values, err := parseIPRange(detailRange, network)
+		if err != nil {
+			return nil, err
+		}
+		offsetList = append(offsetList, values...)
+	}
+
+	if len(offsetList) == 0 {
+		return nil, fmt.Errorf("offset, offsets or range required")
+	}
+
+	first, last := usableOffsets(network)
+	sort.Ints(offsetList)
+	result := []int{}
+	for i, offset := range offsetList {
+		if int64(offset) < first.Int64() || (last.IsInt64() && int64(offset) > last.Int64()) {
+			return nil, fmt.Errorf("offset %d is not a usable address of %s, the network and broadcast addresses can not be used", offset, network.String())
+		}
+		if i == 0 || offset != offsetList[i-1] {
+			result = append(result, offset)
+		}
+	}
+	if len(result) > offsetMaxCount {
+		return nil, fmt.Errorf("%d offsets selected, the maximum is %d", len(result), offsetMaxCount)
+	}
+
+	return result, nil
+}
+
+// rangeRequested returns true if more than the single --offset was asked for
+func rangeRequested() bool {
+	return detailOffsets != "" || detailRange != ""
+}
+
+// runOffsetOperation runs operation for each offset concurrently, returns the errors by offset
+func runOffsetOperation(offsetList []int, operation func(offset int) error) map[int]error {
+	result := map[int]error{}
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+
+	queue := make(chan int)
+	for i := 0; i < offsetWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for offset := range queue {
+				if err := operation(offset); err != nil {
+					lock.Lock()
+					result[offset] = err
+					lock.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, offset := range offsetList {
+		queue <- offset
+	}
+	close(queue)
+	wg.Wait()
+
+	return result
+}
+
+// printOffsetSummary prints the skipped and failed offsets and the totals, returns an error if any failed
+func printOffsetSummary(action string, total int, skipped map[int]string, failed map[int]error) error {
+	offsetList := []int{}
+	for offset := range skipped {
+		offsetList = append(offsetList, offset)
+	}
+	for offset := range failed {
+		offsetList = append(offsetList, offset)
+	}
+	sort.Ints(offsetList)
+
+	for _, offset := range offsetList {
+		if reason, ok := skipped[offset]; ok {
+			fmt.Printf("Offset %d: skipped, %s\n", offset, reason)
+		} else {
+			fmt.Printf("Offset %d: failed, %s\n", offset, failed[offset])
+		}
+	}
+
+	fmt.Printf("%s %d, Skipped %d, Failed %d\n", action, total-len(skipped)-len(failed), len(skipped), len(failed))
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d offset(s) failed", len(failed))
+	}
+	return nil
+}
+
+// blockOffsetUse returns what is using each offset of block, ie: "static address of ...", "reserved", "dynamic"
+func blockOffsetUse(block *contractor.UtilitiesAddressBlock, allocationMap map[int]*addressAllocation) map[int]string {
+	result := map[int]string{}
+	for offset, allocation := range allocationMap {
+		result[offset] = strings.ToLower(allocation.Type)
+		if allocation.Detail != "" {
+			result[offset] += " (" + allocation.Detail + ")"
+		}
+	}
+	if block.GatewayOffset != nil {
+		result[*block.GatewayOffset] = "gateway"
+	}
+	return result
+}
@@ -0,0 +1,350 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	cinp "github.com/cinp/go"
+	"github.com/spf13/cobra"
+	contractor "github.com/t3kton/contractor_goclient"
+)
+
+type auditFinding struct {
+	cinp.BaseObject
+	Severity string `json:"severity"`
+	Category string `json:"category"`
+	Object   string `json:"object"`
+	Message  string `json:"message"`
+}
+
+var auditSeverityOrder = map[string]int{"error": 0, "warning": 1, "info": 2}
+
+// auditData is everything the audit checks need, loaded once
+type auditData struct {
+	structureList     []*contractor.BuildingStructure
+	foundationList    []*contractor.BuildingFoundation
+	blockList         []*contractor.UtilitiesAddressBlock
+	networkSite       map[string]string // network uri -> site uri
+	realIfaceList     []*contractor.UtilitiesRealNetworkInterface
+	abstractIfaceList []*contractor.UtilitiesAbstractNetworkInterface
+	aggIfaceList      []*contractor.UtilitiesAggregatedNetworkInterface
+	addressList       []*contractor.UtilitiesAddress
+	pxeSet            map[string]bool // pxe uri
+}
+
+func loadAuditData(ctx context.Context) (*auditData, error) {
+	result := &auditData{networkSite: map[string]string{}, pxeSet: map[string]bool{}}
+	all := map[string]interface{}{}
+
+	schan, err := contractorClient.BuildingStructureList(ctx, "", all)
+	if err != nil {
+		return nil, err
+	}
+	for v := range schan {
+		result.structureList = append(result.structureList, v)
+	}
+
+	fchan, err := contractorClient.BuildingFoundationList(ctx, "", all)
+	if err != nil {
+		return nil, err
+	}
+	for v := range fchan {
+		result.foundationList = append(result.foundationList, v)
+	}
+
+	bchan, err := contractorClient.UtilitiesAddressBlockList(ctx, "", all)
+	if err != nil {
+		return nil, err
+	}
+	for v := range bchan {
+		result.blockList = append(result.blockList, v)
+	}
+
+	nchan, err := contractorClient.UtilitiesNetworkList(ctx, "", all)
+	if err != nil {
+		return nil, err
+	}
+	for v := range nchan {
+		result.networkSite[v.GetURI()] = stringValue(v.Site)
+	}
+
+	richan, err := contractorClient.UtilitiesRealNetworkInterfaceList(ctx, "", all)
+	if err != nil {
+		return nil, err
+	}
+	for v := range richan {
+		result.realIfaceList = append(result.realIfaceList, v)
+	}
+
+	aichan, err := contractorClient.UtilitiesAbstractNetworkInterfaceList(ctx, "", all)
+	if err != nil {
+		return nil, err
+	}
+	for v := range aichan {
+		result.abstractIfaceList = append(result.abstractIfaceList, v)
+	}
+
+	agchan, err := contractorClient.UtilitiesAggregatedNetworkInterfaceList(ctx, "", all)
+	if err != nil {
+		return nil, err
+	}
+	for v := range agchan {
+		result.aggIfaceList = append(result.aggIfaceList, v)
+	}
+
+	achan, err := contractorClient.UtilitiesAddressList(ctx, "", all)
+	if err != nil {
+		return nil, err
+	}
+	for v := range achan {
+		result.addressList = append(result.addressList, v)
+	}
+
+	pchan, err := contractorClient.BlueprintPXEList(ctx, "", all)
+	if err != nil {
+		return nil, err
+	}
+	for v := range pchan {
+		result.pxeSet[v.GetURI()] = true
+	}
+
+	return result, nil
+}
+
+func auditDuplicateMacs(data *auditData) []*auditFinding {
+	result := []*auditFinding{}
+
+	macMap := map[string][]string{}
+	for _, iface := range data.realIfaceList {
+		mac := normalizeMac(stringValue(iface.Mac))
+		if mac == "" {
+			continue
+		}
+		macMap[mac] = append(macMap[mac], fmt.Sprintf("%s:%s", extractID(stringValue(iface.Foundation)), stringValue(iface.Name)))
+	}
+
+	for mac, ifaceList := range macMap {
+		if len(ifaceList) < 2 {
+			continue
+		}
+		sort.Strings(ifaceList)
+		result = append(result, &auditFinding{Severity: "error", Category: "Duplicate MAC", Object: mac, Message: "used by " + strings.Join(ifaceList, ", ")})
+	}
+
+	return result
+}
+
+func auditOverlappingBlocks(data *auditData) []*auditFinding {
+	result := []*auditFinding{}
+
+	type blockNet struct {
+		name    string
+		site    string
+		network *net.IPNet
+	}
+	netList := []blockNet{}
+	for _, block := range data.blockList {
+		network, err := blockNetwork(block)
+		if err != nil {
+			result = append(result, &auditFinding{Severity: "error", Category: "Invalid AddressBlock", Object: extractID(block.GetURI()), Message: err.Error()})
+			continue
+		}
+		netList = append(netList, blockNet{fmt.Sprintf("%s(%s)", extractID(block.GetURI()), stringValue(block.Name)), stringValue(block.Site), network})
+	}
+
+	for i := 0; i < len(netList); i++ {
+		for j := i + 1; j < len(netList); j++ {
+			a, b := netList[i], netList[j]
+			if !a.network.Contains(b.network.IP) && !b.network.Contains(a.network.IP) {
+				continue
+			}
+			severity := "error"
+			message := fmt.Sprintf("'%s' overlaps AddressBlock %s '%s'", a.network.String(), b.name, b.network.String())
+			if a.site != b.site {
+				severity = "warning"
+				message += fmt.Sprintf(" in another site (%s and %s)", extractID(a.site), extractID(b.site))
+			}
+			result = append(result, &auditFinding{Severity: severity, Category: "Overlapping AddressBlocks", Object: a.name, Message: message})
+		}
+	}
+
+	return result
+}
+
+func auditInterfaceSites(data *auditData) []*auditFinding {
+	result := []*auditFinding{}
+
+	structureSite := map[string]string{}
+	structureName := map[string]string{}
+	for _, structure := range data.structureList {
+		structureSite[structure.GetURI()] = stringValue(structure.Site)
+		structureName[structure.GetURI()] = fmt.Sprintf("%s(%s)", extractID(structure.GetURI()), stringValue(structure.Hostname))
+	}
+
+	check := func(structure string, name string, network string) {
+		if network == "" {
+			return
+		}
+		site, ok := structureSite[structure]
+		if !ok {
+			return
+		}
+		networkSite, ok := data.networkSite[network]
+		if !ok {
+			result = append(result, &auditFinding{Severity: "error", Category: "Orphaned Interface", Object: structureName[structure], Message: fmt.Sprintf("interface '%s' references network %s which does not exist", name, extractID(network))})
+			return
+		}
+		if networkSite != site {
+			result = append(result, &auditFinding{Severity: "error", Category: "Cross Site Interface", Object: structureName[structure], Message: fmt.Sprintf("interface '%s' is on network %s of site '%s', the structure is in site '%s'", name, extractID(network), extractID(networkSite), extractID(site))})
+		}
+	}
+
+	for _, iface := range data.abstractIfaceList {
+		check(stringValue(iface.Structure), stringValue(iface.Name), stringValue(iface.Network))
+	}
+	for _, iface := range data.aggIfaceList {
+		check(stringValue(iface.Structure), stringValue(iface.Name), stringValue(iface.Network))
+	}
+
+	return result
+}
+
+func auditAddressInterfaces(data *auditData) []*auditFinding {
+	result := []*auditFinding{}
+
+	foundationIfaces := map[string]map[string]bool{}
+	for _, iface := range data.realIfaceList {
+		foundation := stringValue(iface.Foundation)
+		if foundationIfaces[foundation] == nil {
+			foundationIfaces[foundation] = map[string]bool{}
+		}
+		foundationIfaces[foundation][stringValue(iface.Name)] = true
+	}
+
+	structureIfaces := map[string]map[string]bool{}
+	structureName := map[string]string{}
+	for _, structure := range data.structureList {
+		networked := networkedURI(structure.GetURI())
+		nameSet := map[string]bool{}
+		for name := range foundationIfaces[stringValue(structure.Foundation)] {
+			nameSet[name] = true
+		}
+		structureIfaces[networked] = nameSet
+		structureName[networked] = fmt.Sprintf("%s(%s)", extractID(structure.GetURI()), stringValue(structure.Hostname))
+	}
+	for _, iface := range data.abstractIfaceList {
+		networked := networkedURI(stringValue(iface.Structure))
+		if nameSet, ok := structureIfaces[networked]; ok {
+			nameSet[stringValue(iface.Name)] = true
+		}
+	}
+	for _, iface := range data.aggIfaceList {
+		networked := networkedURI(stringValue(iface.Structure))
+		if nameSet, ok := structureIfaces[networked]; ok {
+			nameSet[stringValue(iface.Name)] = true
+		}
+	}
+
+	for _, address := range data.addressList {
+		nameSet, ok := structureIfaces[stringValue(address.Networked)]
+		if !ok {
+			continue // not a structure, ie: a complex
+		}
+		name := stringValue(address.InterfaceName)
+		if name == "" || nameSet[name] {
+			continue
+		}
+		result = append(result, &auditFinding{Severity: "warning", Category: "Address Interface Missing", Object: structureName[stringValue(address.Networked)], Message: fmt.Sprintf("address %s is on interface '%s' which the structure does not have", stringValue(address.IPAddress), name)})
+	}
+
+	return result
+}
+
+func auditProvisioning(data *auditData) []*auditFinding {
+	result := []*auditFinding{}
+
+	provisioning := map[string]bool{}
+	for _, iface := range data.realIfaceList {
+		if iface.IsProvisioning != nil && *iface.IsProvisioning {
+			provisioning[stringValue(iface.Foundation)] = true
+		}
+	}
+
+	for _, foundation := range data.foundationList {
+		if !provisioning[foundation.GetURI()] {
+			result = append(result, &auditFinding{Severity: "warning", Category: "No Provisioning Interface", Object: stringValue(foundation.Locator), Message: "foundation has no provisioning interface, bootto will do nothing"})
+		}
+	}
+
+	for _, iface := range data.realIfaceList {
+		pxe := stringValue(iface.Pxe)
+		if pxe == "" || data.pxeSet[pxe] {
+			continue
+		}
+		result = append(result, &auditFinding{Severity: "error", Category: "Missing PXE", Object: fmt.Sprintf("%s:%s", extractID(stringValue(iface.Foundation)), stringValue(iface.Name)), Message: fmt.Sprintf("interface uses PXE '%s' which does not exist", extractID(pxe))})
+	}
+
+	return result
+}
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Check the inventory for consistency problems",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		data, err := loadAuditData(ctx)
+		if err != nil {
+			return err
+		}
+
+		findingList := []*auditFinding{}
+		findingList = append(findingList, auditDuplicateMacs(data)...)
+		findingList = append(findingList, auditOverlappingBlocks(data)...)
+		findingList = append(findingList, auditInterfaceSites(data)...)
+		findingList = append(findingList, auditAddressInterfaces(data)...)
+		findingList = append(findingList, auditProvisioning(data)...)
+
+		sort.SliceStable(findingList, func(i, j int) bool {
+			a, b := findingList[i], findingList[j]
+			if a.Severity != b.Severity {
+				return auditSeverityOrder[a.Severity] < auditSeverityOrder[b.Severity]
+			}
+			if a.Category != b.Category {
+				return a.Category < b.Category
+			}
+			return a.Object < b.Object
+		})
+
+		rl := []cinp.Object{}
+		for _, v := range findingList {
+			rl = append(rl, v)
+		}
+		outputList(rl, []string{"Severity", "Category", "Object", "Finding"}, "{{.Severity}}	{{.Category}}	{{.Object}}	{{.Message}}\n")
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+}
@@ -24,8 +24,9 @@ import (
 
 // bashCompletionCmd represents the completion command
 var bashCompletionCmd = &cobra.Command{
-	Use:   "bash_completion",
-	Short: "Generates bash completion scripts",
+	Use:         "bash_completion",
+	Short:       "Generates bash completion scripts",
+	Annotations: map[string]string{"offline": "true"},
 	Long: `To load completion run:
 
   . <(contractorcli bash_completion)
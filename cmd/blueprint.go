@@ -17,14 +17,12 @@ limitations under the License.
 */
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io"
-	"os"
 	"strings"
 
 	cinp "github.com/cinp/go"
-	"github.com/pelletier/go-toml/v2"
 	"github.com/spf13/cobra"
 )
 
@@ -263,68 +261,45 @@ var blueprintFoundationConfigCmd = &cobra.Command{
 
 		ctx := cmd.Context()
 
-		if configSetName != "" || configFile != "" || configDeleteName != "" {
+		if configModifying() {
 			r, err := contractorClient.BlueprintFoundationBluePrintGet(ctx, blueprintID)
 			if err != nil {
 				return err
 			}
 
-			o := contractorClient.BlueprintFoundationBluePrintNewWithID(blueprintID)
-			o.ConfigValues = r.ConfigValues
-
-			if configSetName != "" {
-				(*o.ConfigValues)[configSetName] = configSetValue
-
-			} else if configFile != "" {
-				var reader io.Reader
-				if configFile == "-" {
-					reader = os.Stdin
-				} else {
-					f, err := os.Open(configFile)
-					if err != nil {
-						return err
-					}
-					defer f.Close()
-					reader = f
-				}
-
-				var newValues map[string]interface{}
-				decoder := toml.NewDecoder(reader)
-				err := decoder.Decode(&newValues)
-				if err != nil {
-					return err
-				}
-
-				for k, v := range newValues {
-					(*o.ConfigValues)[k] = v
-				}
+			values, err := applyConfigChanges(cmd, *r.ConfigValues)
+			if err != nil {
+				return err
+			}
 
-			} else if configDeleteName != "" {
-				delete(*o.ConfigValues, configDeleteName)
+			if err := checkConfigSchema(*r.Name, values); err != nil {
+				return err
 			}
 
+			o := contractorClient.BlueprintFoundationBluePrintNewWithID(blueprintID)
+			o.ConfigValues = &values
+
 			err = o.Update(ctx)
 			if err != nil {
 				return err
 			}
-			outputKV(*o.ConfigValues)
+			return outputConfigValues(cmd, *o.ConfigValues)
+		}
 
-		} else if configFull {
+		if configFull {
 			o := contractorClient.BlueprintBluePrintNewWithID(blueprintID)
 			r, err := o.CallGetConfig(ctx)
 			if err != nil {
 				return err
 			}
-			outputKV(r)
+			return outputConfigValues(cmd, r)
+		}
 
-		} else {
-			o, err := contractorClient.BlueprintFoundationBluePrintGet(ctx, blueprintID)
-			if err != nil {
-				return err
-			}
-			outputKV(*o.ConfigValues)
+		o, err := contractorClient.BlueprintFoundationBluePrintGet(ctx, blueprintID)
+		if err != nil {
+			return err
 		}
-		return nil
+		return outputConfigValues(cmd, *o.ConfigValues)
 	},
 }
 
@@ -379,6 +354,19 @@ var blueprintFoundationScriptLinkCmd = &cobra.Command{
 	},
 }
 
+var blueprintFoundationScriptListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List Scripts of Foundation Blueprint, including those inherited from its parents",
+	Args:  blueprintArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		blueprintID := args[0]
+
+		ctx := cmd.Context()
+
+		return listBlueprintScripts(ctx, blueprintID, "foundation")
+	},
+}
+
 var blueprintFoundationScriptUnlinkCmd = &cobra.Command{
 	Use:   "unlink",
 	Short: "UnLink Script from Foundation Blueprint",
@@ -618,68 +606,45 @@ var blueprintStructureConfigCmd = &cobra.Command{
 
 		ctx := cmd.Context()
 
-		if configSetName != "" || configFile != "" || configDeleteName != "" {
+		if configModifying() {
 			r, err := contractorClient.BlueprintStructureBluePrintGet(ctx, blueprintID)
 			if err != nil {
 				return err
 			}
 
-			o := contractorClient.BlueprintStructureBluePrintNewWithID(blueprintID)
-			o.ConfigValues = r.ConfigValues
-
-			if configSetName != "" {
-				(*o.ConfigValues)[configSetName] = configSetValue
-
-			} else if configFile != "" {
-				var reader io.Reader
-				if configFile == "-" {
-					reader = os.Stdin
-				} else {
-					f, err := os.Open(configFile)
-					if err != nil {
-						return err
-					}
-					defer f.Close()
-					reader = f
-				}
-
-				var newValues map[string]interface{}
-				decoder := toml.NewDecoder(reader)
-				err := decoder.Decode(&newValues)
-				if err != nil {
-					return err
-				}
-
-				for k, v := range newValues {
-					(*o.ConfigValues)[k] = v
-				}
+			values, err := applyConfigChanges(cmd, *r.ConfigValues)
+			if err != nil {
+				return err
+			}
 
-			} else if configDeleteName != "" {
-				delete(*o.ConfigValues, configDeleteName)
+			if err := checkConfigSchema(*r.Name, values); err != nil {
+				return err
 			}
 
+			o := contractorClient.BlueprintStructureBluePrintNewWithID(blueprintID)
+			o.ConfigValues = &values
+
 			err = o.Update(ctx)
 			if err != nil {
 				return err
 			}
-			outputKV(*o.ConfigValues)
+			return outputConfigValues(cmd, *o.ConfigValues)
+		}
 
-		} else if configFull {
+		if configFull {
 			o := contractorClient.BlueprintBluePrintNewWithID(blueprintID)
 			r, err := o.CallGetConfig(ctx)
 			if err != nil {
 				return err
 			}
-			outputKV(r)
+			return outputConfigValues(cmd, r)
+		}
 
-		} else {
-			o, err := contractorClient.BlueprintStructureBluePrintGet(ctx, blueprintID)
-			if err != nil {
-				return err
-			}
-			outputKV(*o.ConfigValues)
+		o, err := contractorClient.BlueprintStructureBluePrintGet(ctx, blueprintID)
+		if err != nil {
+			return err
 		}
-		return nil
+		return outputConfigValues(cmd, *o.ConfigValues)
 	},
 }
 
@@ -735,6 +700,19 @@ var blueprintStructureScriptLinkCmd = &cobra.Command{
 	},
 }
 
+var blueprintStructureScriptListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List Scripts of Structure Blueprint, including those inherited from its parents",
+	Args:  blueprintArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		blueprintID := args[0]
+
+		ctx := cmd.Context()
+
+		return listBlueprintScripts(ctx, blueprintID, "structure")
+	},
+}
+
 var blueprintStructureScriptUnlinkCmd = &cobra.Command{
 	Use:   "unlink",
 	Short: "UnLink Script from Structure Blueprint",
@@ -920,61 +898,93 @@ var scriptEditCmd = &cobra.Command{
 
 		ctx := cmd.Context()
 
-		o := contractorClient.BlueprintScriptNewWithID(scriptID)
+		load := func() (string, error) {
+			r, err := contractorClient.BlueprintScriptGet(ctx, scriptID)
+			if err != nil {
+				return "", err
+			}
+			return *r.Script, nil
+		}
 
-		var newScript string
-		var err error
-
-		for {
-			if scriptFile != "" {
-				var source *os.File
-				if scriptFile == "-" {
-					source = os.Stdin
-				} else {
-					source, err = os.Open(scriptFile)
-					if err != nil {
-						return err
-					}
-				}
-				buf := make([]byte, 4096*1024)
-				len, err := source.Read(buf)
-				if err != nil {
-					return err
-				}
-				newScript = strings.TrimSpace(string(buf[:len]))
+		save := func(value string) error {
+			o := contractorClient.BlueprintScriptNewWithID(scriptID)
+			o.Script = &value
+			return o.Update(ctx)
+		}
 
-			} else {
-				newScript, err = editBuffer(*o.Script)
-				if err != nil {
-					return err
-				}
-			}
+		check := lintTScript
+		if detailNoLint {
+			check = nil
+		}
 
-			o.Script = &newScript
-			err := o.Update(ctx)
-			if err != nil {
-				if scriptFile == "" && strings.HasPrefix(err.Error(), "Invalid Request: 'map[script:[Script is invalid") {
-					fmt.Printf("Error parsing the script:%s\n", err.Error())
-					fmt.Println("Return to Editor?(Y/N)")
-					var b []byte = make([]byte, 1)
-					os.Stdin.Read(b)
-					if b[0] == 'Y' || b[0] == 'y' {
-						continue
-					}
-					fmt.Println("Aborting")
-					break
-				}
-				return err
-			}
-			fmt.Println("Changes Saved")
+		return editRemoteValue("script", load, check, save)
+	},
+}
 
-			break
+var scriptLintCmd = &cobra.Command{
+	Use:         "lint",
+	Short:       "Check a Script file for syntax errors, does not require contractor",
+	Annotations: map[string]string{"offline": "true"},
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("requires a Script filename argument, use '-' for stdin")
 		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename := args[0]
+
+		script, err := readScriptFile(filename)
+		if err != nil {
+			return err
+		}
+
+		if err := lintTScript(script); err != nil {
+			return fmt.Errorf("%s: %s", filename, err)
+		}
+
+		fmt.Printf("%s: OK\n", filename)
 
 		return nil
 	},
 }
 
+type pxeUse struct {
+	cinp.BaseObject
+	Type   string `json:"type"`
+	ID     string `json:"id"`
+	Detail string `json:"detail"`
+}
+
+// pxeUsage returns the Real Network Interfaces and Dynamic Addresses that use the PXE at pxeURI
+func pxeUsage(ctx context.Context, pxeURI string) ([]*pxeUse, error) {
+	result := []*pxeUse{}
+
+	ichan, err := contractorClient.UtilitiesRealNetworkInterfaceList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for v := range ichan {
+		if stringValue(v.Pxe) != pxeURI {
+			continue
+		}
+		result = append(result, &pxeUse{Type: "Interface", ID: extractID(v.GetURI()), Detail: fmt.Sprintf("%s on foundation %s (%s)", stringValue(v.Name), extractID(stringValue(v.Foundation)), stringValue(v.Mac))})
+	}
+
+	dchan, err := contractorClient.UtilitiesDynamicAddressList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for v := range dchan {
+		if stringValue(v.Pxe) != pxeURI {
+			continue
+		}
+		result = append(result, &pxeUse{Type: "Dynamic Address", ID: extractID(v.GetURI()), Detail: fmt.Sprintf("%s in address block %s", stringValue(v.IPAddress), extractID(stringValue(v.AddressBlock)))})
+	}
+
+	return result, nil
+}
+
 var pxeCmd = &cobra.Command{
 	Use:   "pxe",
 	Short: "Work with PXEs",
@@ -1028,55 +1038,138 @@ var pxeListCmd = &cobra.Command{
 	},
 }
 
-var pxeEditScriptCmd = &cobra.Command{
-	Use:   "editscript",
-	Short: "Edit Script of PXE",
-	Args:  scriptArgCheck,
+var pxeCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create New PXE",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		pxeID := args[0]
-
 		ctx := cmd.Context()
 
-		o := contractorClient.BlueprintPXENewWithID(pxeID)
-
-		var newScript string
-		var err error
+		if detailName == "" {
+			return fmt.Errorf("name required")
+		}
 
-		if scriptFile != "" {
-			var source *os.File
-			if scriptFile == "-" {
-				source = os.Stdin
-			} else {
-				source, err = os.Open(scriptFile)
-				if err != nil {
-					return err
-				}
-			}
-			buf := make([]byte, 4096*1024)
-			len, err := source.Read(buf)
+		bootScript := ""
+		if detailScriptFile != "" {
+			value, err := readScriptFile(detailScriptFile)
 			if err != nil {
 				return err
 			}
-			newScript = strings.TrimSpace(string(buf[:len]))
+			bootScript = strings.TrimSpace(value)
+		}
 
-		} else {
-			newScript, err = editBuffer(*o.BootScript)
+		template := ""
+		if detailTemplateFile != "" {
+			value, err := readScriptFile(detailTemplateFile)
 			if err != nil {
 				return err
 			}
+			template = strings.TrimSpace(value)
+		}
+
+		o := contractorClient.BlueprintPXENew()
+		o.Name = &detailName
+		o.BootScript = &bootScript
+		o.Template = &template
+
+		if err := o.Create(ctx); err != nil {
+			return err
+		}
+
+		outputDetail(o, `Id:                    {{.GetURI | extractID}}
+Name:                  {{.Name}}
+Created:               {{.Created}}
+Updated:               {{.Updated}}
+`)
+		return nil
+	},
+}
+
+var pxeDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete PXE, refuses if the PXE is still in use unless --force",
+	Args:  pxeArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pxeID := args[0]
+
+		ctx := cmd.Context()
+
+		o, err := contractorClient.BlueprintPXEGet(ctx, pxeID)
+		if err != nil {
+			return err
 		}
 
-		o.BootScript = &newScript
-		err = o.Update(ctx)
+		useList, err := pxeUsage(ctx, o.GetURI())
 		if err != nil {
 			return err
 		}
-		fmt.Println("Changes Saved")
+		if len(useList) > 0 && !detailForce {
+			return fmt.Errorf("PXE '%s' is in use by %d interface(s)/dynamic address(es), see 'pxe usage', use --force to delete anyway", pxeID, len(useList))
+		}
+
+		if err := o.Delete(ctx); err != nil {
+			return err
+		}
 
 		return nil
 	},
 }
 
+var pxeUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "List the Interfaces and Dynamic Addresses using PXE",
+	Args:  pxeArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pxeID := args[0]
+
+		ctx := cmd.Context()
+
+		o, err := contractorClient.BlueprintPXEGet(ctx, pxeID)
+		if err != nil {
+			return err
+		}
+
+		useList, err := pxeUsage(ctx, o.GetURI())
+		if err != nil {
+			return err
+		}
+
+		rl := []cinp.Object{}
+		for _, v := range useList {
+			rl = append(rl, v)
+		}
+		outputList(rl, []string{"Type", "Id", "Detail"}, "{{.Type}}	{{.ID}}	{{.Detail}}\n")
+
+		return nil
+	},
+}
+
+var pxeEditScriptCmd = &cobra.Command{
+	Use:   "editscript",
+	Short: "Edit Script of PXE",
+	Args:  scriptArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pxeID := args[0]
+
+		ctx := cmd.Context()
+
+		load := func() (string, error) {
+			r, err := contractorClient.BlueprintPXEGet(ctx, pxeID)
+			if err != nil {
+				return "", err
+			}
+			return *r.BootScript, nil
+		}
+
+		save := func(value string) error {
+			o := contractorClient.BlueprintPXENewWithID(pxeID)
+			o.BootScript = &value
+			return o.Update(ctx)
+		}
+
+		return editRemoteValue("boot script", load, nil, save)
+	},
+}
+
 var pxeEditTemplateCmd = &cobra.Command{
 	Use:   "edittemplate",
 	Short: "Edit Template of PXE",
@@ -1086,58 +1179,81 @@ var pxeEditTemplateCmd = &cobra.Command{
 
 		ctx := cmd.Context()
 
-		o := contractorClient.BlueprintPXENewWithID(pxeID)
+		load := func() (string, error) {
+			r, err := contractorClient.BlueprintPXEGet(ctx, pxeID)
+			if err != nil {
+				return "", err
+			}
+			return *r.Template, nil
+		}
 
-		var newTemplate string
-		var err error
+		save := func(value string) error {
+			o := contractorClient.BlueprintPXENewWithID(pxeID)
+			o.Template = &value
+			return o.Update(ctx)
+		}
 
-		if scriptFile != "" {
-			var source *os.File
-			if scriptFile == "-" {
-				source = os.Stdin
-			} else {
-				source, err = os.Open(scriptFile)
-				if err != nil {
-					return err
-				}
+		return editRemoteValue("template", load, nil, save)
+	},
+}
+
+var pxeRenderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Render the Script and Template of PXE with the config of a Structure or Foundation",
+	Args:  pxeArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pxeID := args[0]
+
+		ctx := cmd.Context()
+
+		var values map[string]interface{}
+		if detailStructure != 0 {
+			r, err := contractorClient.BuildingStructureGet(ctx, detailStructure)
+			if err != nil {
+				return err
 			}
-			buf := make([]byte, 4096*1024)
-			len, err := source.Read(buf)
+			values, err = r.CallGetConfig(ctx)
 			if err != nil {
 				return err
 			}
-			newTemplate = strings.TrimSpace(string(buf[:len]))
-
-		} else {
-			newTemplate, err = editBuffer(*o.Template)
+		} else if detailFoundation != "" {
+			r, err := contractorClient.BuildingFoundationGet(ctx, detailFoundation)
+			if err != nil {
+				return err
+			}
+			values, err = r.CallGetConfig(ctx)
 			if err != nil {
 				return err
 			}
+		} else {
+			return fmt.Errorf("structure or foundation required")
 		}
 
-		o.Template = &newTemplate
-		err = o.Update(ctx)
+		o, err := contractorClient.BlueprintPXEGet(ctx, pxeID)
 		if err != nil {
 			return err
 		}
-		fmt.Println("Changes Saved")
+
+		bootScript, err := renderTemplate(*o.BootScript, values)
+		if err != nil {
+			return fmt.Errorf("error rendering the script: %s", err)
+		}
+
+		template, err := renderTemplate(*o.Template, values)
+		if err != nil {
+			return fmt.Errorf("error rendering the template: %s", err)
+		}
+
+		fmt.Printf("----  Script  ----\n%s\n\n----  Template  ----\n%s\n", bootScript, template)
 
 		return nil
 	},
 }
 
 func init() {
-	blueprintFoundationConfigCmd.Flags().BoolVarP(&configFull, "full", "f", false, "Display the Full/Compiled config")
-	blueprintFoundationConfigCmd.Flags().StringVarP(&configSetName, "set-name", "n", "", "Set Config Value Key Name, if set-value is not specified, the value will be set to ''")
-	blueprintFoundationConfigCmd.Flags().StringVarP(&configSetValue, "set-value", "v", "", "Set Config Value, ignored if set-name is not specified")
-	blueprintFoundationConfigCmd.Flags().StringVarP(&configDeleteName, "delete", "d", "", "Delete Config Value Key Name")
-	blueprintFoundationConfigCmd.Flags().StringVarP(&configFile, "file", "i", "", "Load Values from file in TOML format, this will be merged with the existing config, '-' for reading from stdin")
-
-	blueprintStructureConfigCmd.Flags().BoolVarP(&configFull, "full", "f", false, "Display the Full/Compiled config")
-	blueprintStructureConfigCmd.Flags().StringVarP(&configSetName, "set-name", "n", "", "Set Config Value Key Name, if set-value is not specified, the value will be set to ''")
-	blueprintStructureConfigCmd.Flags().StringVarP(&configSetValue, "set-value", "v", "", "Set Config Value, ignored if set-name is not specified")
-	blueprintStructureConfigCmd.Flags().StringVarP(&configDeleteName, "delete", "d", "", "Delete Config Value Key Name")
-	blueprintStructureConfigCmd.Flags().StringVarP(&configFile, "file", "i", "", "Load Values from file in TOML format, this will be merged with the existing config, '-' for reading from stdin")
+	addConfigFlags(blueprintFoundationConfigCmd)
+
+	addConfigFlags(blueprintStructureConfigCmd)
 
 	blueprintFoundationCreateCmd.Flags().StringVarP(&detailName, "name", "n", "", "Name of New Foundation Blueprint")
 	blueprintFoundationCreateCmd.Flags().StringVarP(&detailDescription, "description", "d", "", "Description of New Foundation Blueprint")
@@ -1166,27 +1282,40 @@ func init() {
 	scriptUpdateCmd.Flags().StringVarP(&detailDescription, "description", "d", "", "Update the Description of Script with value")
 
 	scriptEditCmd.Flags().StringVarP(&scriptFile, "file", "f", "", "File to supply the script, use '-' for stdin or omit for interactive editor")
+	scriptEditCmd.Flags().BoolVarP(&detailYes, "yes", "y", false, "Save without asking for confirmation")
+	scriptEditCmd.Flags().BoolVarP(&detailNoLint, "no-lint", "", false, "Save without checking the script for syntax errors")
 
 	pxeEditScriptCmd.Flags().StringVarP(&scriptFile, "file", "f", "", "File to supply the script, use '-' for stdin or omit for interactive editor")
+	pxeEditScriptCmd.Flags().BoolVarP(&detailYes, "yes", "y", false, "Save without asking for confirmation")
 
 	pxeEditTemplateCmd.Flags().StringVarP(&scriptFile, "file", "f", "", "File to supply the template, use '-' for stdin or omit for interactive editor")
+	pxeEditTemplateCmd.Flags().BoolVarP(&detailYes, "yes", "y", false, "Save without asking for confirmation")
+
+	pxeCreateCmd.Flags().StringVarP(&detailName, "name", "n", "", "Name of New PXE")
+	pxeCreateCmd.Flags().StringVarP(&detailScriptFile, "script-file", "s", "", "File to supply the boot script, use '-' for stdin")
+	pxeCreateCmd.Flags().StringVarP(&detailTemplateFile, "template-file", "t", "", "File to supply the template, use '-' for stdin")
+
+	pxeDeleteCmd.Flags().BoolVarP(&detailForce, "force", "", false, "Delete even if the PXE is still in use")
+
+	pxeRenderCmd.Flags().IntVarP(&detailStructure, "structure", "s", 0, "Structure to get the config from")
+	pxeRenderCmd.Flags().StringVarP(&detailFoundation, "foundation", "f", "", "Foundation to get the config from")
 
 	rootCmd.AddCommand(blueprintCmd)
 	blueprintCmd.AddCommand(blueprintFoundationCmd)
 	blueprintFoundationCmd.AddCommand(blueprintFoundationListCmd, blueprintFoundationGetCmd, blueprintFoundationCreateCmd, blueprintFoundationUpdateCmd, blueprintFoundationDeleteCmd, blueprintFoundationConfigCmd)
 
 	blueprintFoundationCmd.AddCommand(blueprintFoundationScriptCmd)
-	blueprintFoundationScriptCmd.AddCommand(blueprintFoundationScriptLinkCmd, blueprintFoundationScriptUnlinkCmd)
+	blueprintFoundationScriptCmd.AddCommand(blueprintFoundationScriptListCmd, blueprintFoundationScriptLinkCmd, blueprintFoundationScriptUnlinkCmd)
 
 	blueprintCmd.AddCommand(blueprintStructureCmd)
 	blueprintStructureCmd.AddCommand(blueprintStructureListCmd, blueprintStructureGetCmd, blueprintStructureCreateCmd, blueprintStructureUpdateCmd, blueprintStructureDeleteCmd, blueprintStructureConfigCmd)
 
 	blueprintStructureCmd.AddCommand(blueprintStructureScriptCmd)
-	blueprintStructureScriptCmd.AddCommand(blueprintStructureScriptLinkCmd, blueprintStructureScriptUnlinkCmd)
+	blueprintStructureScriptCmd.AddCommand(blueprintStructureScriptListCmd, blueprintStructureScriptLinkCmd, blueprintStructureScriptUnlinkCmd)
 
 	blueprintCmd.AddCommand(scriptCmd)
-	scriptCmd.AddCommand(scriptListCmd, scriptGetCmd, scriptCreateCmd, scriptUpdateCmd, scriptDeleteCmd, scriptEditCmd)
+	scriptCmd.AddCommand(scriptListCmd, scriptGetCmd, scriptCreateCmd, scriptUpdateCmd, scriptDeleteCmd, scriptEditCmd, scriptLintCmd)
 
 	blueprintCmd.AddCommand(pxeCmd)
-	pxeCmd.AddCommand(pxeGetCmd, pxeListCmd, pxeEditScriptCmd, pxeEditTemplateCmd)
+	pxeCmd.AddCommand(pxeGetCmd, pxeListCmd, pxeCreateCmd, pxeDeleteCmd, pxeUsageCmd, pxeEditScriptCmd, pxeEditTemplateCmd, pxeRenderCmd)
 }
@@ -0,0 +1,190 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+func blueprintCloneArgCheck(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return errors.New("requires a Source Blueprint Id/Name and a New Blueprint Name argument")
+	}
+	return nil
+}
+
+// cloneBlueprintScripts links the scripts linked to source to target, under the same link names
+func cloneBlueprintScripts(ctx context.Context, source string, target string) (int, error) {
+	count := 0
+
+	vchan, err := contractorClient.BlueprintBluePrintScriptList(ctx, "blueprint", map[string]interface{}{"blueprint": blueprintURI(source)})
+	if err != nil {
+		return 0, err
+	}
+	linkMap := map[string]string{}
+	for v := range vchan {
+		linkMap[stringValue(v.Name)] = stringValue(v.Script)
+	}
+
+	for name, script := range linkMap {
+		link := contractorClient.BlueprintBluePrintScriptNew()
+		blueprint := blueprintURI(target)
+		link.Name = &name
+		link.Blueprint = &blueprint
+		link.Script = &script
+		if err := link.Create(ctx); err != nil {
+			return count, fmt.Errorf("error linking script '%s' as '%s': %s", extractID(script), name, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+func copyConfigValues(values *map[string]interface{}) *map[string]interface{} {
+	result := map[string]interface{}{}
+	if values != nil {
+		for k, v := range *values {
+			result[k] = v
+		}
+	}
+	return &result
+}
+
+func copyStringList(values *[]string) *[]string {
+	result := []string{}
+	if values != nil {
+		result = append(result, (*values)...)
+	}
+	return &result
+}
+
+var blueprintFoundationCloneCmd = &cobra.Command{
+	Use:   "clone",
+	Short: "Clone Foundation Blueprint, including its Script links",
+	Args:  blueprintCloneArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sourceID := args[0]
+		newName := args[1]
+
+		ctx := cmd.Context()
+
+		r, err := contractorClient.BlueprintFoundationBluePrintGet(ctx, sourceID)
+		if err != nil {
+			return err
+		}
+
+		o := contractorClient.BlueprintFoundationBluePrintNew()
+		o.Name = &newName
+		o.Description = r.Description
+		o.ParentList = copyStringList(r.ParentList)
+		o.FoundationTypeList = copyStringList(r.FoundationTypeList)
+		o.PhysicalInterfaceNames = copyStringList(r.PhysicalInterfaceNames)
+		o.ConfigValues = copyConfigValues(r.ConfigValues)
+		if r.ValidationTemplate != nil {
+			o.ValidationTemplate = copyConfigValues(r.ValidationTemplate)
+		}
+		if err := o.Create(ctx); err != nil {
+			return err
+		}
+
+		count, err := cloneBlueprintScripts(ctx, sourceID, newName)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Cloned Foundation Blueprint '%s' to '%s' with %d Script links\n", sourceID, newName, count)
+
+		for _, locator := range detailRepoint {
+			f := contractorClient.BuildingFoundationNewWithID(locator)
+			blueprint := o.GetURI()
+			f.Blueprint = &blueprint
+			if err := f.Update(ctx); err != nil {
+				return fmt.Errorf("error repointing foundation '%s': %s", locator, err)
+			}
+			fmt.Printf("Foundation '%s' now uses '%s'\n", locator, newName)
+		}
+
+		return nil
+	},
+}
+
+var blueprintStructureCloneCmd = &cobra.Command{
+	Use:   "clone",
+	Short: "Clone Structure Blueprint, including its Script links",
+	Args:  blueprintCloneArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sourceID := args[0]
+		newName := args[1]
+
+		ctx := cmd.Context()
+
+		structureList := []int{}
+		for _, item := range detailRepoint {
+			structureID, err := strconv.Atoi(item)
+			if err != nil {
+				return fmt.Errorf("invalid structure id '%s'", item)
+			}
+			structureList = append(structureList, structureID)
+		}
+
+		r, err := contractorClient.BlueprintStructureBluePrintGet(ctx, sourceID)
+		if err != nil {
+			return err
+		}
+
+		o := contractorClient.BlueprintStructureBluePrintNew()
+		o.Name = &newName
+		o.Description = r.Description
+		o.ParentList = copyStringList(r.ParentList)
+		o.FoundationBlueprintList = copyStringList(r.FoundationBlueprintList)
+		o.ConfigValues = copyConfigValues(r.ConfigValues)
+		if err := o.Create(ctx); err != nil {
+			return err
+		}
+
+		count, err := cloneBlueprintScripts(ctx, sourceID, newName)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Cloned Structure Blueprint '%s' to '%s' with %d Script links\n", sourceID, newName, count)
+
+		for _, structureID := range structureList {
+			s := contractorClient.BuildingStructureNewWithID(structureID)
+			blueprint := o.GetURI()
+			s.Blueprint = &blueprint
+			if err := s.Update(ctx); err != nil {
+				return fmt.Errorf("error repointing structure '%d': %s", structureID, err)
+			}
+			fmt.Printf("Structure '%d' now uses '%s'\n", structureID, newName)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	blueprintFoundationCloneCmd.Flags().StringSliceVarP(&detailRepoint, "repoint", "p", []string{}, "Foundations(locators) to move to the new Blueprint, comma separated or repeated")
+	blueprintStructureCloneCmd.Flags().StringSliceVarP(&detailRepoint, "repoint", "p", []string{}, "Structures(ids) to move to the new Blueprint, comma separated or repeated")
+
+	blueprintFoundationCmd.AddCommand(blueprintFoundationCloneCmd)
+	blueprintStructureCmd.AddCommand(blueprintStructureCloneCmd)
+}
@@ -0,0 +1,358 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Sync of Blueprint Scripts and PXEs with a local directory, the layout is:
+
+	<dir>/contractor.toml         metadata, lists the scripts and pxes and their files
+	<dir>/scripts/<name>.ts       script of BlueprintScript <name>
+	<dir>/pxe/<name>.ipxe         boot_script of BlueprintPXE <name>
+	<dir>/pxe/<name>.tmpl         template of BlueprintPXE <name>
+
+Only scripts and pxes listed in the metadata file are pushed, nothing is deleted from contractor.
+*/
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+)
+
+const syncMetadataFile = "contractor.toml"
+
+type syncScript struct {
+	Description string `toml:"description"`
+	File        string `toml:"file"`
+}
+
+type syncPXE struct {
+	BootScript string `toml:"boot_script"`
+	Template   string `toml:"template"`
+}
+
+type syncMetadata struct {
+	Scripts map[string]syncScript `toml:"scripts"`
+	PXEs    map[string]syncPXE    `toml:"pxe"`
+}
+
+func syncArgCheck(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return errors.New("requires a directory argument")
+	}
+	return nil
+}
+
+// syncCheckName makes sure name is usable as a file name
+func syncCheckName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+		return fmt.Errorf("name '%s' can not be used as a file name", name)
+	}
+	return nil
+}
+
+func syncWriteFile(dir string, filename string, value string) error {
+	if value != "" && !strings.HasSuffix(value, "\n") {
+		value += "\n"
+	}
+	filename = filepath.Join(dir, filename)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, []byte(value), 0644)
+}
+
+func syncReadFile(dir string, filename string) (string, error) {
+	if filename == "" {
+		return "", nil
+	}
+	value, err := readScriptFile(filepath.Join(dir, filename))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(value), nil
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+var blueprintSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync Blueprint Scripts and PXEs with a local directory",
+}
+
+var blueprintSyncPullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Write all Blueprint Scripts and PXEs to a directory",
+	Args:  syncArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := args[0]
+
+		ctx := cmd.Context()
+
+		metadata := syncMetadata{Scripts: map[string]syncScript{}, PXEs: map[string]syncPXE{}}
+
+		schan, err := contractorClient.BlueprintScriptList(ctx, "", map[string]interface{}{})
+		if err != nil {
+			return err
+		}
+		for v := range schan {
+			name := stringValue(v.Name)
+			if err := syncCheckName(name); err != nil {
+				return err
+			}
+			entry := syncScript{Description: stringValue(v.Description), File: filepath.ToSlash(filepath.Join("scripts", name+".ts"))}
+			if err := syncWriteFile(dir, entry.File, stringValue(v.Script)); err != nil {
+				return err
+			}
+			metadata.Scripts[name] = entry
+		}
+
+		pchan, err := contractorClient.BlueprintPXEList(ctx, "", map[string]interface{}{})
+		if err != nil {
+			return err
+		}
+		for v := range pchan {
+			name := stringValue(v.Name)
+			if err := syncCheckName(name); err != nil {
+				return err
+			}
+			entry := syncPXE{BootScript: filepath.ToSlash(filepath.Join("pxe", name+".ipxe")), Template: filepath.ToSlash(filepath.Join("pxe", name+".tmpl"))}
+			if err := syncWriteFile(dir, entry.BootScript, stringValue(v.BootScript)); err != nil {
+				return err
+			}
+			if err := syncWriteFile(dir, entry.Template, stringValue(v.Template)); err != nil {
+				return err
+			}
+			metadata.PXEs[name] = entry
+		}
+
+		var buff bytes.Buffer
+		if err := toml.NewEncoder(&buff).Encode(metadata); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, syncMetadataFile), buff.Bytes(), 0644); err != nil {
+			return err
+		}
+
+		fmt.Printf("Pulled %d Scripts and %d PXEs to '%s'\n", len(metadata.Scripts), len(metadata.PXEs), dir)
+
+		return nil
+	},
+}
+
+var blueprintSyncPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Upload changed Blueprint Scripts and PXEs from a directory",
+	Args:  syncArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := args[0]
+
+		ctx := cmd.Context()
+
+		buff, err := os.ReadFile(filepath.Join(dir, syncMetadataFile))
+		if err != nil {
+			return err
+		}
+		metadata := syncMetadata{}
+		if err := toml.Unmarshal(buff, &metadata); err != nil {
+			return fmt.Errorf("error loading '%s': %s", syncMetadataFile, err)
+		}
+
+		scriptNameList := []string{}
+		for name := range metadata.Scripts {
+			scriptNameList = append(scriptNameList, name)
+		}
+		sort.Strings(scriptNameList)
+
+		pxeNameList := []string{}
+		for name := range metadata.PXEs {
+			pxeNameList = append(pxeNameList, name)
+		}
+		sort.Strings(pxeNameList)
+
+		// load and check everything before changing anything
+		scriptMap := map[string]string{}
+		for _, name := range scriptNameList {
+			entry := metadata.Scripts[name]
+			if entry.File == "" {
+				return fmt.Errorf("script '%s' does not specify a file", name)
+			}
+			script, err := syncReadFile(dir, entry.File)
+			if err != nil {
+				return err
+			}
+			if !detailNoLint {
+				if err := lintTScript(script); err != nil {
+					return fmt.Errorf("%s: %s", entry.File, err)
+				}
+			}
+			scriptMap[name] = script
+		}
+
+		bootScriptMap := map[string]string{}
+		templateMap := map[string]string{}
+		for _, name := range pxeNameList {
+			entry := metadata.PXEs[name]
+			if bootScriptMap[name], err = syncReadFile(dir, entry.BootScript); err != nil {
+				return err
+			}
+			if templateMap[name], err = syncReadFile(dir, entry.Template); err != nil {
+				return err
+			}
+		}
+
+		action := ""
+		if syncDryRun {
+			action = "Would be "
+		}
+		changed := 0
+
+		schan, err := contractorClient.BlueprintScriptList(ctx, "", map[string]interface{}{})
+		if err != nil {
+			return err
+		}
+		current := map[string][2]string{}
+		for v := range schan {
+			current[stringValue(v.Name)] = [2]string{strings.TrimSpace(stringValue(v.Script)), stringValue(v.Description)}
+		}
+
+		for _, name := range scriptNameList {
+			script := scriptMap[name]
+			description := metadata.Scripts[name].Description
+
+			existing, ok := current[name]
+			if !ok {
+				fmt.Printf("Script '%s': %sCreated\n", name, action)
+				changed++
+				if syncDryRun {
+					continue
+				}
+				o := contractorClient.BlueprintScriptNew()
+				o.Name = &name
+				o.Description = &description
+				o.Script = &script
+				if err := o.Create(ctx); err != nil {
+					return fmt.Errorf("error creating script '%s': %s", name, err)
+				}
+				continue
+			}
+
+			fieldList := []string{}
+			o := contractorClient.BlueprintScriptNewWithID(name)
+			if existing[0] != script {
+				fieldList = append(fieldList, "script")
+				o.Script = &script
+			}
+			if existing[1] != description {
+				fieldList = append(fieldList, "description")
+				o.Description = &description
+			}
+			if len(fieldList) == 0 {
+				continue
+			}
+
+			fmt.Printf("Script '%s': %sUpdated (%s)\n", name, action, strings.Join(fieldList, ", "))
+			changed++
+			if syncDryRun {
+				continue
+			}
+			if err := o.Update(ctx); err != nil {
+				return fmt.Errorf("error updating script '%s': %s", name, err)
+			}
+		}
+
+		pchan, err := contractorClient.BlueprintPXEList(ctx, "", map[string]interface{}{})
+		if err != nil {
+			return err
+		}
+		current = map[string][2]string{}
+		for v := range pchan {
+			current[stringValue(v.Name)] = [2]string{strings.TrimSpace(stringValue(v.BootScript)), strings.TrimSpace(stringValue(v.Template))}
+		}
+
+		for _, name := range pxeNameList {
+			bootScript := bootScriptMap[name]
+			template := templateMap[name]
+
+			existing, ok := current[name]
+			if !ok {
+				fmt.Printf("PXE '%s': %sCreated\n", name, action)
+				changed++
+				if syncDryRun {
+					continue
+				}
+				o := contractorClient.BlueprintPXENew()
+				o.Name = &name
+				o.BootScript = &bootScript
+				o.Template = &template
+				if err := o.Create(ctx); err != nil {
+					return fmt.Errorf("error creating pxe '%s': %s", name, err)
+				}
+				continue
+			}
+
+			fieldList := []string{}
+			o := contractorClient.BlueprintPXENewWithID(name)
+			if existing[0] != bootScript {
+				fieldList = append(fieldList, "boot_script")
+				o.BootScript = &bootScript
+			}
+			if existing[1] != template {
+				fieldList = append(fieldList, "template")
+				o.Template = &template
+			}
+			if len(fieldList) == 0 {
+				continue
+			}
+
+			fmt.Printf("PXE '%s': %sUpdated (%s)\n", name, action, strings.Join(fieldList, ", "))
+			changed++
+			if syncDryRun {
+				continue
+			}
+			if err := o.Update(ctx); err != nil {
+				return fmt.Errorf("error updating pxe '%s': %s", name, err)
+			}
+		}
+
+		if changed == 0 {
+			fmt.Println("No Changes")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	blueprintSyncPushCmd.Flags().BoolVarP(&syncDryRun, "dry-run", "n", false, "Show what would be uploaded without changing anything")
+	blueprintSyncPushCmd.Flags().BoolVarP(&detailNoLint, "no-lint", "", false, "Upload without checking the scripts for syntax errors")
+
+	blueprintCmd.AddCommand(blueprintSyncCmd)
+	blueprintSyncCmd.AddCommand(blueprintSyncPullCmd, blueprintSyncPushCmd)
+}
@@ -0,0 +1,465 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	cinp "github.com/cinp/go"
+	"github.com/spf13/cobra"
+)
+
+type blueprintNode struct {
+	Name                 string
+	Kind                 string // "foundation" or "structure"
+	Description          string
+	ParentList           []string
+	FoundationBlueprints []string
+}
+
+type blueprintUsage struct {
+	cinp.BaseObject
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Blueprint string `json:"blueprint"`
+	Via       string `json:"via"`
+}
+
+type blueprintScriptLink struct {
+	cinp.BaseObject
+	Name        string `json:"name"`
+	Script      string `json:"script"`
+	Description string `json:"description"`
+	Blueprint   string `json:"blueprint"`
+}
+
+// loadBlueprints returns all the foundation and structure blueprints by name
+func loadBlueprints(ctx context.Context) (map[string]*blueprintNode, error) {
+	result := map[string]*blueprintNode{}
+
+	fchan, err := contractorClient.BlueprintFoundationBluePrintList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for v := range fchan {
+		node := &blueprintNode{Name: stringValue(v.Name), Kind: "foundation", Description: stringValue(v.Description)}
+		if v.ParentList != nil {
+			for _, parent := range *v.ParentList {
+				node.ParentList = append(node.ParentList, extractID(parent))
+			}
+		}
+		result[node.Name] = node
+	}
+
+	schan, err := contractorClient.BlueprintStructureBluePrintList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for v := range schan {
+		node := &blueprintNode{Name: stringValue(v.Name), Kind: "structure", Description: stringValue(v.Description)}
+		if v.ParentList != nil {
+			for _, parent := range *v.ParentList {
+				node.ParentList = append(node.ParentList, extractID(parent))
+			}
+		}
+		if v.FoundationBlueprintList != nil {
+			for _, foundation := range *v.FoundationBlueprintList {
+				node.FoundationBlueprints = append(node.FoundationBlueprints, extractID(foundation))
+			}
+		}
+		result[node.Name] = node
+	}
+
+	return result, nil
+}
+
+// blueprintChildren returns the names of the blueprints that have name as a parent, sorted
+func blueprintChildren(nodeMap map[string]*blueprintNode, name string) []string {
+	result := []string{}
+	for _, node := range nodeMap {
+		for _, parent := range node.ParentList {
+			if parent == name {
+				result = append(result, node.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// blueprintDescendants returns name and all the blueprints inheriting from it, mapped to the blueprint
+// they directly inherit through ("" for name itself)
+func blueprintDescendants(nodeMap map[string]*blueprintNode, name string) map[string]string {
+	result := map[string]string{name: ""}
+	queue := []string{name}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range blueprintChildren(nodeMap, current) {
+			if _, ok := result[child]; ok {
+				continue
+			}
+			result[child] = current
+			queue = append(queue, child)
+		}
+	}
+	return result
+}
+
+// blueprintURI returns the URI of the base BluePrint model, which is what BluePrintScript links to
+func blueprintURI(name string) string {
+	return "/api/v1/BluePrint/BluePrint:" + name + ":"
+}
+
+// blueprintScriptLinks returns the scripts linked directly to blueprint, scriptMap is the script
+// descriptions by script id
+func blueprintScriptLinks(ctx context.Context, blueprint string, scriptMap map[string]string) ([]*blueprintScriptLink, error) {
+	result := []*blueprintScriptLink{}
+
+	vchan, err := contractorClient.BlueprintBluePrintScriptList(ctx, "blueprint", map[string]interface{}{"blueprint": blueprintURI(blueprint)})
+	if err != nil {
+		return nil, err
+	}
+	for v := range vchan {
+		script := extractID(stringValue(v.Script))
+		result = append(result, &blueprintScriptLink{Name: stringValue(v.Name), Script: script, Description: scriptMap[script], Blueprint: blueprint})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// resolveBlueprintScripts returns the scripts available to blueprint once inheritance is resolved, the
+// blueprint's own links win, then the parents are searched depth first in order
+func resolveBlueprintScripts(ctx context.Context, nodeMap map[string]*blueprintNode, blueprint string, scriptMap map[string]string) ([]*blueprintScriptLink, error) {
+	result := []*blueprintScriptLink{}
+	found := map[string]bool{}
+	visited := map[string]bool{}
+
+	var walk func(name string) error
+	walk = func(name string) error {
+		if visited[name] {
+			return nil
+		}
+		visited[name] = true
+
+		links, err := blueprintScriptLinks(ctx, name, scriptMap)
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			if !found[link.Name] {
+				found[link.Name] = true
+				result = append(result, link)
+			}
+		}
+
+		if node, ok := nodeMap[name]; ok {
+			for _, parent := range node.ParentList {
+				if err := walk(parent); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(blueprint); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// loadScriptDescriptions returns the description of all the scripts by script id
+func loadScriptDescriptions(ctx context.Context) (map[string]string, error) {
+	result := map[string]string{}
+
+	vchan, err := contractorClient.BlueprintScriptList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for v := range vchan {
+		result[extractID(v.GetURI())] = stringValue(v.Description)
+	}
+
+	return result, nil
+}
+
+// listBlueprintScripts outputs the resolved scripts of blueprint, which must be of kind
+func listBlueprintScripts(ctx context.Context, blueprint string, kind string) error {
+	nodeMap, err := loadBlueprints(ctx)
+	if err != nil {
+		return err
+	}
+	node, ok := nodeMap[blueprint]
+	if !ok || node.Kind != kind {
+		return fmt.Errorf("%s blueprint '%s' not found", kind, blueprint)
+	}
+
+	scriptMap, err := loadScriptDescriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	linkList, err := resolveBlueprintScripts(ctx, nodeMap, blueprint, scriptMap)
+	if err != nil {
+		return err
+	}
+
+	rl := []cinp.Object{}
+	for _, v := range linkList {
+		rl = append(rl, v)
+	}
+	outputList(rl, []string{"Link Name", "Script", "Description", "Supplied By"}, "{{.Name}}	{{.Script}}	{{.Description}}	{{.Blueprint}}\n")
+
+	return nil
+}
+
+func writeBlueprintTree(builder *strings.Builder, nodeMap map[string]*blueprintNode, name string, depth int, path map[string]bool) {
+	node := nodeMap[name]
+	builder.WriteString(strings.Repeat("  ", depth) + name)
+	if node.Description != "" {
+		builder.WriteString(" - " + node.Description)
+	}
+	if len(node.FoundationBlueprints) > 0 {
+		builder.WriteString(" [foundations: " + strings.Join(node.FoundationBlueprints, ", ") + "]")
+	}
+	if path[name] {
+		builder.WriteString(" (loop)\n")
+		return
+	}
+	builder.WriteString("\n")
+
+	path[name] = true
+	for _, child := range blueprintChildren(nodeMap, name) {
+		writeBlueprintTree(builder, nodeMap, child, depth+1, path)
+	}
+	delete(path, name)
+}
+
+func blueprintTreeText(nodeMap map[string]*blueprintNode) string {
+	var builder strings.Builder
+
+	for _, kind := range []string{"foundation", "structure"} {
+		rootList := []string{}
+		for _, node := range nodeMap {
+			if node.Kind != kind {
+				continue
+			}
+			isRoot := true
+			for _, parent := range node.ParentList {
+				if _, ok := nodeMap[parent]; ok {
+					isRoot = false
+				}
+			}
+			if isRoot {
+				rootList = append(rootList, node.Name)
+			}
+		}
+		sort.Strings(rootList)
+
+		if kind == "foundation" {
+			builder.WriteString("Foundation Blueprints:\n")
+		} else {
+			builder.WriteString("\nStructure Blueprints:\n")
+		}
+		for _, name := range rootList {
+			writeBlueprintTree(&builder, nodeMap, name, 1, map[string]bool{})
+		}
+	}
+
+	return builder.String()
+}
+
+func blueprintTreeDot(nodeMap map[string]*blueprintNode) string {
+	nameList := []string{}
+	for name := range nodeMap {
+		nameList = append(nameList, name)
+	}
+	sort.Strings(nameList)
+
+	var builder strings.Builder
+	builder.WriteString("digraph blueprints {\n")
+	builder.WriteString("  rankdir=BT;\n")
+	for _, name := range nameList {
+		shape := "ellipse"
+		if nodeMap[name].Kind == "foundation" {
+			shape = "box"
+		}
+		fmt.Fprintf(&builder, "  %q [shape=%s];\n", name, shape)
+	}
+	for _, name := range nameList {
+		for _, parent := range nodeMap[name].ParentList {
+			fmt.Fprintf(&builder, "  %q -> %q;\n", name, parent)
+		}
+		for _, foundation := range nodeMap[name].FoundationBlueprints {
+			fmt.Fprintf(&builder, "  %q -> %q [style=dashed];\n", name, foundation)
+		}
+	}
+	builder.WriteString("}\n")
+
+	return builder.String()
+}
+
+var blueprintTreeCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Show the inheritance hierarchy of the Blueprints",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		nodeMap, err := loadBlueprints(ctx)
+		if err != nil {
+			return err
+		}
+
+		if detailDOT {
+			fmt.Print(blueprintTreeDot(nodeMap))
+		} else {
+			fmt.Print(blueprintTreeText(nodeMap))
+		}
+
+		return nil
+	},
+}
+
+var blueprintUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "List the Foundations, Structures and Scripts using a Blueprint, directly or through inheritance",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("requires a Blueprint Name argument")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		blueprintID := args[0]
+
+		ctx := cmd.Context()
+
+		nodeMap, err := loadBlueprints(ctx)
+		if err != nil {
+			return err
+		}
+		if _, ok := nodeMap[blueprintID]; !ok {
+			return fmt.Errorf("blueprint '%s' not found", blueprintID)
+		}
+
+		descendants := blueprintDescendants(nodeMap, blueprintID)
+		via := func(name string) string {
+			if name == blueprintID {
+				return "direct"
+			}
+			chain := []string{name}
+			for current := descendants[name]; current != blueprintID; current = descendants[current] {
+				chain = append(chain, current)
+			}
+			return "inherited via " + strings.Join(chain, " -> ")
+		}
+
+		usageList := []*blueprintUsage{}
+
+		fchan, err := contractorClient.BuildingFoundationList(ctx, "", map[string]interface{}{})
+		if err != nil {
+			return err
+		}
+		for v := range fchan {
+			blueprint := extractID(stringValue(v.Blueprint))
+			if _, ok := descendants[blueprint]; ok {
+				usageList = append(usageList, &blueprintUsage{Type: "Foundation", ID: stringValue(v.Locator), Blueprint: blueprint, Via: via(blueprint)})
+			}
+		}
+
+		schan, err := contractorClient.BuildingStructureList(ctx, "", map[string]interface{}{})
+		if err != nil {
+			return err
+		}
+		for v := range schan {
+			blueprint := extractID(stringValue(v.Blueprint))
+			if _, ok := descendants[blueprint]; ok {
+				usageList = append(usageList, &blueprintUsage{Type: "Structure", ID: fmt.Sprintf("%s(%s)", extractID(v.GetURI()), stringValue(v.Hostname)), Blueprint: blueprint, Via: via(blueprint)})
+			}
+		}
+
+		sort.SliceStable(usageList, func(i, j int) bool {
+			if usageList[i].Type != usageList[j].Type {
+				return usageList[i].Type < usageList[j].Type
+			}
+			return usageList[i].ID < usageList[j].ID
+		})
+
+		scriptMap, err := loadScriptDescriptions(ctx)
+		if err != nil {
+			return err
+		}
+
+		nameList := []string{}
+		for name := range descendants {
+			nameList = append(nameList, name)
+		}
+		sort.Strings(nameList)
+
+		linkList := []*blueprintScriptLink{}
+		for _, name := range nameList {
+			links, err := blueprintScriptLinks(ctx, name, scriptMap)
+			if err != nil {
+				return err
+			}
+			linkList = append(linkList, links...)
+		}
+
+		if asJSON {
+			outputDetail(map[string]interface{}{"usage": usageList, "scripts": linkList}, "")
+			return nil
+		}
+
+		rl := []cinp.Object{}
+		for _, v := range usageList {
+			rl = append(rl, v)
+		}
+		fmt.Println("Used By:")
+		outputList(rl, []string{"Type", "Id", "Blueprint", "Via"}, "{{.Type}}	{{.ID}}	{{.Blueprint}}	{{.Via}}\n")
+
+		rl = []cinp.Object{}
+		for _, v := range linkList {
+			rl = append(rl, v)
+		}
+		fmt.Println("Linked Scripts:")
+		outputList(rl, []string{"Blueprint", "Link Name", "Script", "Description"}, "{{.Blueprint}}	{{.Name}}	{{.Script}}	{{.Description}}\n")
+
+		return nil
+	},
+}
+
+func init() {
+	blueprintTreeCmd.Flags().BoolVarP(&detailDOT, "dot", "", false, "Output in Graphviz DOT format")
+
+	blueprintCmd.AddCommand(blueprintTreeCmd, blueprintUsageCmd)
+}
@@ -16,19 +16,23 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-var configSetName, configSetValue, configDeleteName, configFile string
-var configFull, detailIsPrimary bool
+import "time"
+
+var configSetName, configSetValue, configDeleteName, configFile, configExport, configFormat, configSchema string
+var configFull, configReplace, detailIsPrimary bool
 var detailHostname, detailSite, detailBlueprint, detailFoundation, detailInterfaceName string
 var detailPrimary int
 var detailSecondary string
 var detailSubnet, detailReason, detailPXE string
 var detailPrefix, detailGatewayOffset, detailOffset int
 var scriptFile string
+var syncDryRun, detailYes, detailDOT, detailForce, detailNoLint bool
 var detailAddParent, detailDeleteParent, detailAddFoundationBluePrint, detailDeleteFoundationBluePrint, detailAddType, detailDeleteType, detailAddIfaceName, detailDeleteIfaceName string
 var detailName, detailDescription, detailParent, detailCorners string
 var detailZone int
 var detailDatacenter, detailCluster string
 var detailHost int
+var detailStructure int
 var detailBuiltPercentage int
 var detailMember int
 var detailMembers []int
@@ -41,3 +45,21 @@ var detailIsProvisioning bool
 var detailNetwork int
 var detailAddressBlock, detailVlan, detailMTU int
 var detailFailLikelihood, detailDelayVariance int
+var detailRepoint []string
+var detailScriptFile, detailTemplateFile string
+var detailOffsets, detailRange string
+var detailCIDR, detailGatewayIP string
+var detailWarn, detailCrit int
+var exportZone, exportOut string
+var exportNSList []string
+var dnsSerial int
+var dhcpFormat, dhcpNextServer string
+var dhcpBootFiles map[string]string
+var inventoryList, inventoryWithConfig, inventoryRefresh bool
+var inventoryHost string
+var inventoryCacheTTL time.Duration
+var targetsFormat string
+var targetsPorts []string
+var managedFile, managedDomain, managedUser string
+var managedCheck bool
+var detailAliasIndex, detailPointer int
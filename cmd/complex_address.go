@@ -0,0 +1,224 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	contractor "github.com/t3kton/contractor_goclient"
+)
+
+const complexAddressLong = `Contractor does not link Complexes to a Networked, so by convention the addresses of
+a Complex (ie: VIPs) are attached to the Networked, that is not a Structure, in the Site
+of the Complex with the name of the Complex as its hostname.  'next' and 'add' create
+that Networked if it does not exist.  If more than one Networked matches, use the
+'networked address' commands with the id of the correct Networked instead.`
+
+func complexAddressArgCheck(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return errors.New("requires a Complex Id(Name) and Address Id argument")
+	}
+	return nil
+}
+
+// complexNetworked returns the Networked holding the addresses of the complex, see complexAddressLong.  If create
+// is set and it does not exist, it is created.
+func complexNetworked(ctx context.Context, complexID string, create bool) (*contractor.UtilitiesNetworked, error) {
+	c, err := contractorClient.BuildingComplexGet(ctx, complexID)
+	if err != nil {
+		return nil, err
+	}
+	site := stringValue(c.Site)
+
+	structureSet := map[string]bool{} // by networked uri
+	schan, err := contractorClient.BuildingStructureList(ctx, "site", map[string]interface{}{"site": site})
+	if err != nil {
+		return nil, err
+	}
+	for v := range schan {
+		structureSet[networkedURI(v.GetURI())] = true
+	}
+
+	matchList := []*contractor.UtilitiesNetworked{}
+	vchan, err := contractorClient.UtilitiesNetworkedList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for v := range vchan {
+		if stringValue(v.Hostname) == stringValue(c.Name) && stringValue(v.Site) == site && !structureSet[v.GetURI()] {
+			matchList = append(matchList, v)
+		}
+	}
+
+	switch len(matchList) {
+	case 1:
+		return matchList[0], nil
+	case 0:
+	default:
+		idList := []string{}
+		for _, v := range matchList {
+			idList = append(idList, extractID(v.GetURI()))
+		}
+		return nil, fmt.Errorf("more than one Networked (%s) matches complex '%s', use the 'networked address' commands instead", strings.Join(idList, ", "), complexID)
+	}
+
+	if !create {
+		return nil, fmt.Errorf("complex '%s' does not have any addresses", complexID)
+	}
+
+	result := contractorClient.UtilitiesNetworkedNew()
+	result.Hostname = c.Name
+	result.Site = c.Site
+	if err := result.Create(ctx); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Created Networked %s for the addresses of complex '%s'\n", extractID(result.GetURI()), complexID)
+
+	return result, nil
+}
+
+// complexAddress returns an error if the address addressID does not belong to the complex
+func complexAddress(ctx context.Context, complexID string, addressID int) error {
+	n, err := complexNetworked(ctx, complexID, false)
+	if err != nil {
+		return err
+	}
+
+	o, err := contractorClient.UtilitiesAddressGet(ctx, addressID)
+	if err != nil {
+		return err
+	}
+	if stringValue(o.Networked) != n.GetURI() {
+		return fmt.Errorf("address %d does not belong to complex '%s'", addressID, complexID)
+	}
+
+	return nil
+}
+
+var complexAddressCmd = &cobra.Command{
+	Use:   "address",
+	Short: "Work with Complex Ip Addresses, ie: VIPs",
+	Long:  complexAddressLong,
+}
+
+var complexAddressListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all Ip Addresses attached to a Complex",
+	Args:  complexArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		complexID := args[0]
+		ctx := cmd.Context()
+
+		o, err := complexNetworked(ctx, complexID, false)
+		if err != nil {
+			return err
+		}
+
+		return outputAddressList(ctx, o.GetURI())
+	},
+}
+
+var complexAddressNextCmd = &cobra.Command{
+	Use:   "next",
+	Short: "Assign Next available IP address in Address Block to Complex",
+	Long:  complexAddressLong,
+	Args:  complexArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		complexID := args[0]
+		ctx := cmd.Context()
+
+		o, err := complexNetworked(ctx, complexID, true)
+		if err != nil {
+			return err
+		}
+
+		return nextAddress(ctx, o.GetURI())
+	},
+}
+
+var complexAddressAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add an IP address to Complex",
+	Long:  complexAddressLong,
+	Args:  complexArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		complexID := args[0]
+		ctx := cmd.Context()
+
+		o, err := complexNetworked(ctx, complexID, true)
+		if err != nil {
+			return err
+		}
+
+		return addAddress(ctx, o.GetURI())
+	},
+}
+
+var complexAddressUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update an IP address of a Complex",
+	Args:  complexAddressArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		complexID := args[0]
+		addressID, err := strconv.Atoi(args[1])
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+
+		if err := complexAddress(ctx, complexID, addressID); err != nil {
+			return err
+		}
+
+		return updateAddress(ctx, addressID)
+	},
+}
+
+var complexAddressDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete IP address from a Complex",
+	Args:  complexAddressArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		complexID := args[0]
+		addressID, err := strconv.Atoi(args[1])
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+
+		if err := complexAddress(ctx, complexID, addressID); err != nil {
+			return err
+		}
+
+		return deleteAddress(ctx, addressID)
+	},
+}
+
+func init() {
+	addressFlags(complexAddressNextCmd, complexAddressAddCmd, complexAddressUpdateCmd)
+
+	complexCmd.AddCommand(complexAddressCmd)
+	complexAddressCmd.AddCommand(complexAddressListCmd, complexAddressNextCmd, complexAddressAddCmd, complexAddressUpdateCmd, complexAddressDeleteCmd)
+}
@@ -0,0 +1,269 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cinp "github.com/cinp/go"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var configFormats = []string{"toml", "json", "yaml"}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Work with Config Values",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the Full/Compiled config of a Structure against a schema",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if detailStructure == 0 {
+			return fmt.Errorf("structure required")
+		}
+
+		if configSchema == "" {
+			return fmt.Errorf("schema required")
+		}
+
+		ctx := cmd.Context()
+
+		o, err := contractorClient.BuildingStructureGet(ctx, detailStructure)
+		if err != nil {
+			return err
+		}
+
+		filename, err := findSchemaFile(configSchema, extractID(*o.Blueprint))
+		if err != nil {
+			return err
+		}
+		if filename == "" {
+			return fmt.Errorf("no schema found for blueprint '%s' in '%s'", extractID(*o.Blueprint), configSchema)
+		}
+
+		schema, err := loadSchema(filename)
+		if err != nil {
+			return err
+		}
+
+		values, err := o.CallGetConfig(ctx)
+		if err != nil {
+			return err
+		}
+
+		violationList := validateConfig(schema, values, false)
+
+		rl := []cinp.Object{}
+		for _, v := range violationList {
+			rl = append(rl, v)
+		}
+		outputList(rl, []string{"Path", "Violation"}, "{{.Path}}	{{.Message}}\n")
+
+		if len(violationList) > 0 {
+			return fmt.Errorf("config failed validation with %d violation(s)", len(violationList))
+		}
+
+		return nil
+	},
+}
+
+// configFileFormat returns the format to use for filename, the --format flag
+// wins if it was specified, otherwise the extension of the file is used
+func configFileFormat(cmd *cobra.Command, filename string) (string, error) {
+	if !cmd.Flags().Changed("format") {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".json":
+			return "json", nil
+		case ".yaml", ".yml":
+			return "yaml", nil
+		case ".toml":
+			return "toml", nil
+		}
+	}
+
+	for _, format := range configFormats {
+		if format == configFormat {
+			return format, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown format '%s', must be one of %s", configFormat, strings.Join(configFormats, ", "))
+}
+
+func decodeConfigValues(reader io.Reader, format string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+
+	switch format {
+	case "toml":
+		if err := toml.NewDecoder(reader).Decode(&values); err != nil {
+			return nil, err
+		}
+	case "json":
+		if err := json.NewDecoder(reader).Decode(&values); err != nil {
+			return nil, err
+		}
+	case "yaml":
+		if err := yaml.NewDecoder(reader).Decode(&values); err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+func encodeConfigValues(writer io.Writer, values map[string]interface{}, format string) error {
+	switch format {
+	case "toml":
+		return toml.NewEncoder(writer).Encode(values)
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(values)
+	case "yaml":
+		encoder := yaml.NewEncoder(writer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(values); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+
+	return fmt.Errorf("unknown format '%s'", format)
+}
+
+func readConfigFile(cmd *cobra.Command, filename string) (map[string]interface{}, error) {
+	format, err := configFileFormat(cmd, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader
+	if filename == "-" {
+		reader = os.Stdin
+	} else {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		reader = f
+	}
+
+	return decodeConfigValues(reader, format)
+}
+
+func writeConfigFile(cmd *cobra.Command, filename string, values map[string]interface{}) error {
+	format, err := configFileFormat(cmd, filename)
+	if err != nil {
+		return err
+	}
+
+	var buff bytes.Buffer
+	if err := encodeConfigValues(&buff, values, format); err != nil {
+		return err
+	}
+
+	if filename == "-" {
+		_, err = os.Stdout.Write(buff.Bytes())
+		return err
+	}
+
+	return os.WriteFile(filename, buff.Bytes(), 0644)
+}
+
+// configModifying returns true if the flags on the config command will cause the config values to be changed
+func configModifying() bool {
+	return configSetName != "" || configFile != "" || configDeleteName != ""
+}
+
+// applyConfigChanges applies the set/file/delete flags of the config commands to values,
+// values is not modified, the updated map is returned
+func applyConfigChanges(cmd *cobra.Command, values map[string]interface{}) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	for k, v := range values {
+		result[k] = v
+	}
+
+	if configSetName != "" {
+		result[configSetName] = configSetValue
+
+	} else if configFile != "" {
+		newValues, err := readConfigFile(cmd, configFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if configReplace {
+			result = newValues
+		} else {
+			for k, v := range newValues {
+				result[k] = v
+			}
+		}
+
+	} else if configDeleteName != "" {
+		delete(result, configDeleteName)
+	}
+
+	return result, nil
+}
+
+// outputConfigValues writes the config values to the export file if one was specified, otherwise outputs them to stdout,
+// in --format if specified
+func outputConfigValues(cmd *cobra.Command, values map[string]interface{}) error {
+	if configExport != "" {
+		return writeConfigFile(cmd, configExport, values)
+	}
+
+	if cmd.Flags().Changed("format") {
+		return writeConfigFile(cmd, "-", values)
+	}
+
+	outputKV(values)
+	return nil
+}
+
+// addConfigFlags adds the flags common to all the config commands
+func addConfigFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&configFull, "full", "f", false, "Display the Full/Compiled config")
+	cmd.Flags().StringVarP(&configSetName, "set-name", "n", "", "Set Config Value Key Name, if set-value is not specified, the value will be set to ''")
+	cmd.Flags().StringVarP(&configSetValue, "set-value", "v", "", "Set Config Value, ignored if set-name is not specified") // TODO: make a numberic version
+	cmd.Flags().StringVarP(&configDeleteName, "delete", "d", "", "Delete Config Value Key Name")
+	cmd.Flags().StringVarP(&configFile, "file", "i", "", "Load Values from file, this will be merged with the existing config unless --replace is specified, '-' for reading from stdin")
+	cmd.Flags().BoolVarP(&configReplace, "replace", "r", false, "Replace the whole config with the values loaded from file instead of merging")
+	cmd.Flags().StringVarP(&configExport, "export", "e", "", "Write the config values to file, in a form that can be loaded back in with --file, '-' for writing to stdout")
+	cmd.Flags().StringVarP(&configFormat, "format", "t", "toml", "Format of the file for --file and --export, and of the output, one of toml, json, yaml, if not specified the file extension is used when recognized")
+	cmd.Flags().StringVarP(&configSchema, "schema", "m", "", "Validate the config values against this schema file before saving, if a directory the schema is the file named after the blueprint, or _site for sites, validation is skipped if there is no such file")
+}
+
+func init() {
+	configValidateCmd.Flags().IntVarP(&detailStructure, "structure", "s", 0, "Structure to validate the config of")
+	configValidateCmd.Flags().StringVarP(&configSchema, "schema", "m", "", "Schema file to validate against, if a directory the schema is the file named after the structure's blueprint")
+
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+}
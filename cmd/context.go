@@ -0,0 +1,200 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cinp "github.com/cinp/go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type contextEntry struct {
+	cinp.BaseObject
+	Current  bool   `json:"current"`
+	Name     string `json:"name"`
+	Host     string `json:"host"`
+	Username string `json:"username"`
+}
+
+// resolveContext sets activeContext from --context, then the CONTRACTOR_CONTEXT environment variable, then the
+// current_context of the [contractor] section.  If the context does not exist contextErr is set, commands other than
+// the context commands fail with it, so a stale current_context can be fixed with use-context.
+func resolveContext() {
+	contextErr = nil
+	activeContext = contextName
+	if activeContext == "" {
+		activeContext = os.Getenv("CONTRACTOR_CONTEXT")
+	}
+	if activeContext == "" {
+		activeContext = viper.GetString("contractor.current_context")
+	}
+
+	if activeContext != "" && !viper.IsSet("contexts."+activeContext) {
+		contextErr = fmt.Errorf("context '%s' not found in the config file", activeContext)
+	}
+}
+
+// contextCredentialKeys are only taken from the context, so a context never logs in with the credentials of another
+var contextCredentialKeys = map[string]bool{"username": true, "password": true, "password_command": true, "credential_store": true}
+
+// configString returns key from the [contexts.<name>] section of the active context, falling back
+// to the [contractor] section for keys other than the credentials
+func configString(key string) string {
+	if activeContext != "" && (contextCredentialKeys[key] || viper.IsSet("contexts."+activeContext+"."+key)) {
+		return viper.GetString("contexts." + activeContext + "." + key)
+	}
+	return viper.GetString("contractor." + key)
+}
+
+// contextNames returns the sorted names of the contexts in the config file
+func contextNames() []string {
+	result := []string{}
+	for name := range viper.GetStringMap("contexts") {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// setIniValue sets key in section of the ini file filename to value, leaving the rest of the file untouched
+func setIniValue(filename string, section string, key string, value string) error {
+	buff, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	lineList := strings.Split(strings.TrimRight(string(buff), "\n"), "\n")
+	newLine := fmt.Sprintf("%s: %s", key, value)
+
+	inSection := false
+	insertAt := -1
+	for i, line := range lineList {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			inSection = strings.TrimSpace(trimmed[1:len(trimmed)-1]) == section
+			if inSection {
+				insertAt = i + 1
+			}
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, ";") {
+			insertAt = i + 1
+		}
+		if sep := strings.IndexAny(trimmed, "=:"); sep != -1 && strings.TrimSpace(trimmed[:sep]) == key {
+			lineList[i] = newLine
+			return writeFileAtomic(filename, []byte(strings.Join(lineList, "\n")+"\n"), info.Mode().Perm())
+		}
+	}
+
+	if insertAt == -1 {
+		lineList = append(lineList, "", "["+section+"]", newLine)
+	} else {
+		lineList = append(lineList[:insertAt], append([]string{newLine}, lineList[insertAt:]...)...)
+	}
+
+	return writeFileAtomic(filename, []byte(strings.Join(lineList, "\n")+"\n"), info.Mode().Perm())
+}
+
+var configUseContextCmd = &cobra.Command{
+	Use:         "use-context",
+	Short:       "Set the current context in the config file",
+	Annotations: map[string]string{"offline": "true", "context": "optional"},
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("requires a Context Name argument")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		if !viper.IsSet("contexts." + name) {
+			return fmt.Errorf("context '%s' not found in the config file", name)
+		}
+
+		filename := viper.ConfigFileUsed()
+		if filename == "" {
+			return fmt.Errorf("no config file found")
+		}
+		if ext := filepath.Ext(filename); ext != ".ini" {
+			return fmt.Errorf("only ini config files can be updated, set 'current_context' in '%s' by hand", filename)
+		}
+
+		if err := setIniValue(filename, "contractor", "current_context", name); err != nil {
+			return err
+		}
+
+		fmt.Printf("Switched to context '%s'\n", name)
+
+		return nil
+	},
+}
+
+var configGetContextsCmd = &cobra.Command{
+	Use:         "get-contexts",
+	Short:       "List the contexts in the config file",
+	Annotations: map[string]string{"offline": "true", "context": "optional"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rl := []cinp.Object{}
+		for _, name := range contextNames() {
+			rl = append(rl, &contextEntry{
+				Current:  name == activeContext,
+				Name:     name,
+				Host:     viper.GetString("contexts." + name + ".host"),
+				Username: viper.GetString("contexts." + name + ".username"),
+			})
+		}
+		outputList(rl, []string{"Current", "Name", "Host", "Username"}, "{{if .Current}}*{{end}}	{{.Name}}	{{.Host}}	{{.Username}}\n")
+
+		return nil
+	},
+}
+
+var configCurrentContextCmd = &cobra.Command{
+	Use:         "current-context",
+	Short:       "Show the current context",
+	Annotations: map[string]string{"offline": "true", "context": "optional"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if contextErr != nil {
+			return contextErr
+		}
+		if activeContext == "" {
+			return fmt.Errorf("current context is not set")
+		}
+		fmt.Println(activeContext)
+
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configUseContextCmd, configGetContextsCmd, configCurrentContextCmd)
+}
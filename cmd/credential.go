@@ -0,0 +1,170 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// credentialStore is somewhere to look up passwords, selected with the credential_store config value
+type credentialStore interface {
+	// Get returns the password of username on host, "" if the store does not have one
+	Get(host string, username string) (string, error)
+}
+
+var credentialStores = map[string]credentialStore{}
+
+// passwordCache holds passwords for the life of the process, so the shell only prompts once, never written to disk
+var passwordCache = map[string]string{}
+
+// runCredentialTool runs name with args, returns its output without the trailing newline, "" if it exits non zero
+func runCredentialTool(name string, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// secretServiceStore uses the Linux Secret Service (ie: GNOME Keyring, KWallet) via secret-tool, store with:
+//
+//	secret-tool store --label=contractorcli service contractorcli host <host> username <username>
+type secretServiceStore struct{}
+
+func (s *secretServiceStore) Get(host string, username string) (string, error) {
+	return runCredentialTool("secret-tool", "lookup", "service", "contractorcli", "host", host, "username", username)
+}
+
+// keychainStore uses the macOS Keychain via security, store with:
+//
+//	security add-generic-password -s contractorcli:<host> -a <username> -w
+type keychainStore struct{}
+
+func (s *keychainStore) Get(host string, username string) (string, error) {
+	return runCredentialTool("security", "find-generic-password", "-s", "contractorcli:"+host, "-a", username, "-w")
+}
+
+// runPasswordCommand runs command with the shell and returns the first line of its output
+func runPasswordCommand(command string) (string, error) {
+	var stdout bytes.Buffer
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = os.Stdin // for things like gpg that may ask for a passphrase
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("password_command failed: %s", err)
+	}
+
+	password, _, _ := strings.Cut(stdout.String(), "\n")
+	password = strings.TrimRight(password, "\r")
+	if password == "" {
+		return "", fmt.Errorf("password_command did not output a password")
+	}
+
+	return password, nil
+}
+
+func promptPassword(host string, username string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no password for '%s' on '%s', set password_command or credential_store in the config file", username, host)
+	}
+
+	fmt.Fprintf(os.Stderr, "Password for %s@%s: ", username, host)
+	buff, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buff), nil
+}
+
+// contractorPassword returns the password of the current context, from the first of password, password_command,
+// credential_store or asking the user.  In a named context these only come from the context, never [contractor],
+// see contextCredentialKeys.
+func contractorPassword() (string, error) {
+	host := configString("host")
+	username := configString("username")
+	key := host + "|" + username
+
+	if password, ok := passwordCache[key]; ok {
+		return password, nil
+	}
+
+	password := configString("password")
+
+	if password == "" {
+		if command := configString("password_command"); command != "" {
+			var err error
+			password, err = runPasswordCommand(command)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if password == "" {
+		if name := configString("credential_store"); name != "" {
+			store, ok := credentialStores[name]
+			if !ok {
+				nameList := []string{}
+				for item := range credentialStores {
+					nameList = append(nameList, item)
+				}
+				sort.Strings(nameList)
+				return "", fmt.Errorf("unknown credential_store '%s', expected one of %s", name, strings.Join(nameList, ", "))
+			}
+			var err error
+			password, err = store.Get(host, username)
+			if err != nil {
+				return "", fmt.Errorf("error getting the password from credential_store '%s': %s", name, err)
+			}
+		}
+	}
+
+	if password == "" {
+		var err error
+		password, err = promptPassword(host, username)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	passwordCache[key] = password
+
+	return password, nil
+}
+
+func init() {
+	credentialStores["secret-service"] = &secretServiceStore{}
+	credentialStores["keychain"] = &keychainStore{}
+}
@@ -0,0 +1,251 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const diffContext = 3
+
+// unifiedDiff returns a unified diff of the lines of a and b, "" if they are the same
+func unifiedDiff(aName string, bName string, a string, b string) string {
+	if a == b {
+		return ""
+	}
+
+	aLines := strings.Split(a, "\n")
+	bLines := strings.Split(b, "\n")
+
+	// longest common subsequence table, lcs[i][j] is the length for aLines[i:] and bLines[j:]
+	lcs := make([][]int, len(aLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bLines)+1)
+	}
+	for i := len(aLines) - 1; i >= 0; i-- {
+		for j := len(bLines) - 1; j >= 0; j-- {
+			if aLines[i] == bLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type diffLine struct {
+		op   byte
+		text string
+		a, b int // line index in a and b before this line
+	}
+
+	lineList := []diffLine{}
+	i, j := 0, 0
+	for i < len(aLines) || j < len(bLines) {
+		switch {
+		case i < len(aLines) && j < len(bLines) && aLines[i] == bLines[j]:
+			lineList = append(lineList, diffLine{' ', aLines[i], i, j})
+			i++
+			j++
+		case i < len(aLines) && (j == len(bLines) || lcs[i+1][j] >= lcs[i][j+1]):
+			lineList = append(lineList, diffLine{'-', aLines[i], i, j})
+			i++
+		default:
+			lineList = append(lineList, diffLine{'+', bLines[j], i, j})
+			j++
+		}
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "--- %s\n+++ %s\n", aName, bName)
+
+	for start := 0; start < len(lineList); {
+		if lineList[start].op == ' ' {
+			start++
+			continue
+		}
+
+		// extend the hunk while changes are within 2 * context lines of each other
+		first := start - diffContext
+		if first < 0 {
+			first = 0
+		}
+		last := start
+		for k := start; k < len(lineList) && k-last <= 2*diffContext; k++ {
+			if lineList[k].op != ' ' {
+				last = k
+			}
+		}
+		end := last + diffContext + 1
+		if end > len(lineList) {
+			end = len(lineList)
+		}
+
+		aCount, bCount := 0, 0
+		for _, line := range lineList[first:end] {
+			if line.op != '+' {
+				aCount++
+			}
+			if line.op != '-' {
+				bCount++
+			}
+		}
+		aStart, bStart := lineList[first].a+1, lineList[first].b+1
+		if aCount == 0 {
+			aStart--
+		}
+		if bCount == 0 {
+			bStart--
+		}
+
+		fmt.Fprintf(&result, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for _, line := range lineList[first:end] {
+			fmt.Fprintf(&result, "%c%s\n", line.op, line.text)
+		}
+
+		start = end
+	}
+
+	return result.String()
+}
+
+// askChoice asks the user question until one of the choices is answered, returns the choice
+func askChoice(question string, choices []string) string {
+	for {
+		fmt.Printf("%s(%s) ", question, strings.Join(choices, "/"))
+		answer, err := stdinReader.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		for _, choice := range choices {
+			if answer == choice {
+				return choice
+			}
+		}
+		if err != nil {
+			return ""
+		}
+	}
+}
+
+// editRemoteValue edits a value that is stored in contractor, load returns the current value,
+// check (optional) validates the new value and save uploads it.  The changes are shown as a diff and
+// need to be confirmed (unless --yes), if the value in contractor changed while editing, the user is
+// warned before it is overwritten.  If scriptFile is set the new value comes from that file instead of
+// the editor.
+func editRemoteValue(name string, load func() (string, error), check func(string) error, save func(string) error) error {
+	if scriptFile == "-" && !detailYes {
+		return fmt.Errorf("the %s is read from stdin, so it can not be confirmed, use --yes", name)
+	}
+
+	// when not interactive (ie: answers piped in), aborting is an error so automation does not think it was saved
+	interactive := term.IsTerminal(int(os.Stdin.Fd()))
+	abort := func() error {
+		fmt.Println("Aborting")
+		if !interactive {
+			return fmt.Errorf("%s not saved", name)
+		}
+		return nil
+	}
+
+	original, err := load()
+	if err != nil {
+		return err
+	}
+
+	newValue := original
+	for {
+		if scriptFile != "" {
+			newValue, err = readScriptFile(scriptFile)
+			if err != nil {
+				return err
+			}
+		} else {
+			newValue, err = editBuffer(newValue)
+			if err != nil {
+				return err
+			}
+		}
+
+		if check != nil {
+			if err := check(newValue); err != nil {
+				if scriptFile != "" {
+					return fmt.Errorf("%s: %s", scriptFile, err)
+				}
+				fmt.Printf("Error parsing the %s: %s\n", name, err.Error())
+				if askYesNo("Return to Editor?") {
+					continue
+				}
+				return abort()
+			}
+		}
+
+		newValue = strings.TrimSpace(newValue)
+		if newValue == strings.TrimSpace(original) {
+			fmt.Println("No Changes")
+			return nil
+		}
+
+		fmt.Print(unifiedDiff(name+" (contractor)", name+" (edited)", strings.TrimSpace(original), newValue))
+
+		if !detailYes {
+			choices := []string{"s", "a"}
+			question := "Save or Abort? "
+			if scriptFile == "" {
+				choices = []string{"s", "e", "a"}
+				question = "Save, re-Edit or Abort? "
+			}
+			switch askChoice(question, choices) {
+			case "s":
+			case "e":
+				continue
+			default:
+				return abort()
+			}
+		}
+
+		current, err := load()
+		if err != nil {
+			return err
+		}
+		if current != original {
+			fmt.Printf("WARNING: the %s was changed in contractor since it was loaded, saving will overwrite these changes:\n", name)
+			fmt.Print(unifiedDiff(name+" (loaded)", name+" (contractor)", strings.TrimSpace(original), strings.TrimSpace(current)))
+			if !detailYes && !askYesNo("Overwrite?") {
+				return abort()
+			}
+		}
+
+		if err := save(newValue); err != nil {
+			if scriptFile != "" {
+				return err
+			}
+			fmt.Printf("Error saving the %s: %s\n", name, err.Error())
+			if askYesNo("Return to Editor?") {
+				original = current
+				continue
+			}
+			return abort()
+		}
+		fmt.Println("Changes Saved")
+
+		return nil
+	}
+}
@@ -0,0 +1,270 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	contractor "github.com/t3kton/contractor_goclient"
+)
+
+type exportAddress struct {
+	InterfaceName string
+	AliasIndex    int
+	IsPrimary     bool
+	IP            net.IP
+	Network       *net.IPNet
+	Gateway       net.IP
+	Mac           string
+	Pxe           string
+}
+
+type exportHost struct {
+	StructureID int
+	Hostname    string
+	Site        string
+	Foundation  string
+	Blueprint   string
+	State       string
+	AddressList []*exportAddress
+}
+
+// PrimaryAddress returns the primary address of the host, nil if it has none
+func (h *exportHost) PrimaryAddress() *exportAddress {
+	for _, address := range h.AddressList {
+		if address.IsPrimary {
+			return address
+		}
+	}
+	return nil
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export Contractor data for other systems",
+}
+
+// exportSiteURI returns the URI of the site, "" if site is ""
+func exportSiteURI(ctx context.Context, site string) (string, error) {
+	if site == "" {
+		return "", nil
+	}
+	r, err := contractorClient.SiteSiteGet(ctx, site)
+	if err != nil {
+		return "", err
+	}
+	return r.GetURI(), nil
+}
+
+// loadExportHosts returns the structures, optionally limited to the site at siteURI, and their addresses,
+// sorted by hostname.  Addresses are sorted primary first, then by interface name and alias index.
+func loadExportHosts(ctx context.Context, siteURI string) ([]*exportHost, error) {
+	filterName := ""
+	filterValues := map[string]interface{}{}
+	if siteURI != "" {
+		filterName = "site"
+		filterValues["site"] = siteURI
+	}
+
+	hostMap := map[string]*exportHost{} // by networked uri
+	hostList := []*exportHost{}
+	foundationMap := map[string]*exportHost{} // by foundation uri
+
+	schan, err := contractorClient.BuildingStructureList(ctx, filterName, filterValues)
+	if err != nil {
+		return nil, err
+	}
+	for v := range schan {
+		host := &exportHost{
+			StructureID: *v.ID,
+			Hostname:    stringValue(v.Hostname),
+			Site:        extractID(stringValue(v.Site)),
+			Foundation:  extractID(stringValue(v.Foundation)),
+			Blueprint:   extractID(stringValue(v.Blueprint)),
+			State:       stringValue(v.State),
+			AddressList: []*exportAddress{},
+		}
+		hostMap[networkedURI(v.GetURI())] = host
+		if v.Foundation != nil && *v.Foundation != "" {
+			foundationMap[*v.Foundation] = host
+		}
+		hostList = append(hostList, host)
+	}
+
+	ifaceMap := map[string]*contractor.UtilitiesRealNetworkInterface{} // by foundation uri + ":" + interface name
+	ichan, err := contractorClient.UtilitiesRealNetworkInterfaceList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for v := range ichan {
+		if _, ok := foundationMap[stringValue(v.Foundation)]; ok {
+			ifaceMap[stringValue(v.Foundation)+":"+stringValue(v.Name)] = v
+		}
+	}
+
+	foundationURI := map[*exportHost]string{}
+	for uri, host := range foundationMap {
+		foundationURI[host] = uri
+	}
+
+	bchan, err := contractorClient.UtilitiesAddressBlockList(ctx, filterName, filterValues)
+	if err != nil {
+		return nil, err
+	}
+	blockList := []*contractor.UtilitiesAddressBlock{}
+	for v := range bchan {
+		blockList = append(blockList, v)
+	}
+
+	for _, block := range blockList {
+		network, err := blockNetwork(block)
+		if err != nil {
+			return nil, err
+		}
+		var gateway net.IP
+		if block.GatewayOffset != nil {
+			gateway = offsetIP(network, *block.GatewayOffset)
+		}
+
+		vchan, err := contractorClient.UtilitiesAddressList(ctx, "address_block", map[string]interface{}{"address_block": block.GetURI()})
+		if err != nil {
+			return nil, err
+		}
+		for v := range vchan {
+			host, ok := hostMap[stringValue(v.Networked)]
+			if !ok || v.Offset == nil {
+				continue
+			}
+			address := &exportAddress{
+				InterfaceName: stringValue(v.InterfaceName),
+				IsPrimary:     v.IsPrimary != nil && *v.IsPrimary,
+				IP:            offsetIP(network, *v.Offset),
+				Network:       network,
+				Gateway:       gateway,
+			}
+			if iface, ok := ifaceMap[foundationURI[host]+":"+address.InterfaceName]; ok {
+				address.Mac = normalizeMac(stringValue(iface.Mac))
+				address.Pxe = extractID(stringValue(iface.Pxe))
+			}
+			if v.AliasIndex != nil {
+				address.AliasIndex = *v.AliasIndex
+			}
+			host.AddressList = append(host.AddressList, address)
+		}
+	}
+
+	for _, host := range hostList {
+		sort.SliceStable(host.AddressList, func(i, j int) bool {
+			a, b := host.AddressList[i], host.AddressList[j]
+			if a.IsPrimary != b.IsPrimary {
+				return a.IsPrimary
+			}
+			if a.InterfaceName != b.InterfaceName {
+				return a.InterfaceName < b.InterfaceName
+			}
+			return a.AliasIndex < b.AliasIndex
+		})
+	}
+
+	sort.SliceStable(hostList, func(i, j int) bool {
+		if hostList[i].Hostname != hostList[j].Hostname {
+			return hostList[i].Hostname < hostList[j].Hostname
+		}
+		return hostList[i].StructureID < hostList[j].StructureID
+	})
+
+	return hostList, nil
+}
+
+// loadStructureComplexes returns the sorted names of the complexes each structure is a member of, by structure id
+func loadStructureComplexes(ctx context.Context) (map[int][]string, error) {
+	result := map[int][]string{}
+	cchan, err := contractorClient.BuildingComplexStructureList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for v := range cchan {
+		id, err := strconv.Atoi(extractID(stringValue(v.Structure)))
+		if err != nil {
+			continue
+		}
+		result[id] = append(result[id], extractID(stringValue(v.Complex)))
+	}
+
+	for _, complexList := range result {
+		sort.Strings(complexList)
+	}
+
+	return result, nil
+}
+
+// addressName returns the dns name (relative to the zone) of address, the primary address is the
+// hostname, others are <hostname>-<interface name>, with -<alias index> if it is an alias
+func addressName(host *exportHost, address *exportAddress) string {
+	if address.IsPrimary {
+		return host.Hostname
+	}
+	name := host.Hostname + "-" + address.InterfaceName
+	if address.AliasIndex != 0 {
+		name += "-" + strconv.Itoa(address.AliasIndex)
+	}
+	return name
+}
+
+// writeFileAtomic writes content to filename via a temporary file in the same directory
+// and a rename, so readers never see a partial file
+func writeFileAtomic(filename string, content []byte, perm os.FileMode) error {
+	tmpfile, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpfile.Name())
+
+	if _, err := tmpfile.Write(content); err != nil {
+		tmpfile.Close()
+		return err
+	}
+	if err := tmpfile.Chmod(perm); err != nil {
+		tmpfile.Close()
+		return err
+	}
+	if err := tmpfile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpfile.Name(), filename)
+}
+
+// writeExport writes content to filename, or stdout if filename is "" or "-"
+func writeExport(filename string, content string) error {
+	if filename == "" || filename == "-" {
+		_, err := fmt.Print(content)
+		return err
+	}
+	return writeFileAtomic(filename, []byte(content), 0644)
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+}
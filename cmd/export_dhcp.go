@@ -0,0 +1,355 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type dhcpPool struct {
+	start net.IP
+	end   net.IP
+	pxe   string
+}
+
+type dhcpHost struct {
+	name string
+	mac  string
+	ip   net.IP
+	pxe  string
+}
+
+type dhcpSubnet struct {
+	id       int
+	name     string
+	network  *net.IPNet
+	gateway  net.IP
+	poolList []*dhcpPool
+	hostList []*dhcpHost
+}
+
+// dhcpBootFile returns the boot filename for the PXE pxe, the --boot-file mapping if set, otherwise the name of the PXE
+func dhcpBootFile(pxe string) string {
+	if filename, ok := dhcpBootFiles[pxe]; ok {
+		return filename
+	}
+	return pxe
+}
+
+// loadDHCPSubnets returns the IPv4 address blocks of the site at siteURI with their dynamic pools and
+// the reservations for the interfaces of hostList that have a MAC, sorted by network
+func loadDHCPSubnets(ctx context.Context, siteURI string, hostList []*exportHost) ([]*dhcpSubnet, error) {
+	subnetMap := map[string]*dhcpSubnet{} // by network
+	subnetList := []*dhcpSubnet{}
+
+	bchan, err := contractorClient.UtilitiesAddressBlockList(ctx, "site", map[string]interface{}{"site": siteURI})
+	if err != nil {
+		return nil, err
+	}
+	for v := range bchan {
+		network, err := blockNetwork(v)
+		if err != nil {
+			return nil, err
+		}
+		if network.IP.To4() == nil {
+			continue
+		}
+		subnet := &dhcpSubnet{id: *v.ID, name: stringValue(v.Name), network: network}
+		if v.GatewayOffset != nil {
+			subnet.gateway = offsetIP(network, *v.GatewayOffset)
+		}
+		subnetMap[network.String()] = subnet
+		subnetList = append(subnetList, subnet)
+	}
+
+	for _, subnet := range subnetList {
+		block := contractorClient.UtilitiesAddressBlockNewWithID(subnet.id)
+		dchan, err := contractorClient.UtilitiesDynamicAddressList(ctx, "address_block", map[string]interface{}{"address_block": block.GetURI()})
+		if err != nil {
+			return nil, err
+		}
+		offsetMap := map[int]string{} // pxe by offset
+		for v := range dchan {
+			if v.Offset != nil {
+				offsetMap[*v.Offset] = extractID(stringValue(v.Pxe))
+			}
+		}
+		offsetList := []int{}
+		for offset := range offsetMap {
+			offsetList = append(offsetList, offset)
+		}
+		sort.Ints(offsetList)
+
+		// consecutive offsets with the same PXE are one pool
+		var pool *dhcpPool
+		for i, offset := range offsetList {
+			if pool == nil || offset != offsetList[i-1]+1 || offsetMap[offset] != pool.pxe {
+				pool = &dhcpPool{start: offsetIP(subnet.network, offset), pxe: offsetMap[offset]}
+				subnet.poolList = append(subnet.poolList, pool)
+			}
+			pool.end = offsetIP(subnet.network, offset)
+		}
+	}
+
+	for _, host := range hostList {
+		for _, address := range host.AddressList {
+			// aliases share the interface MAC, only the base address gets a reservation
+			if address.AliasIndex != 0 {
+				continue
+			}
+			subnet, ok := subnetMap[address.Network.String()]
+			if !ok || address.Mac == "" || host.Hostname == "" {
+				continue
+			}
+			subnet.hostList = append(subnet.hostList, &dhcpHost{name: addressName(host, address), mac: address.Mac, ip: address.IP, pxe: address.Pxe})
+		}
+	}
+
+	sort.SliceStable(subnetList, func(i, j int) bool {
+		return bytes.Compare(subnetList[i].network.IP.To4(), subnetList[j].network.IP.To4()) < 0
+	})
+
+	return subnetList, nil
+}
+
+func renderDHCPISC(subnetList []*dhcpSubnet) string {
+	var builder strings.Builder
+	builder.WriteString("# Generated by contractorcli from Contractor, do not edit\n")
+
+	bootHints := func(indent string, pxe string) {
+		if pxe == "" {
+			return
+		}
+		if dhcpNextServer != "" {
+			fmt.Fprintf(&builder, "%snext-server %s;\n", indent, dhcpNextServer)
+		}
+		fmt.Fprintf(&builder, "%sfilename \"%s\";\n", indent, dhcpBootFile(pxe))
+	}
+
+	for _, subnet := range subnetList {
+		fmt.Fprintf(&builder, "\n# %s\n", subnet.name)
+		fmt.Fprintf(&builder, "subnet %s netmask %s {\n", subnet.network.IP, net.IP(subnet.network.Mask))
+		if subnet.gateway != nil {
+			fmt.Fprintf(&builder, "  option routers %s;\n", subnet.gateway)
+		}
+		for _, pool := range subnet.poolList {
+			builder.WriteString("  pool {\n")
+			fmt.Fprintf(&builder, "    range %s %s;\n", pool.start, pool.end)
+			bootHints("    ", pool.pxe)
+			builder.WriteString("  }\n")
+		}
+		builder.WriteString("}\n")
+
+		for _, host := range subnet.hostList {
+			fmt.Fprintf(&builder, "host %s {\n", host.name)
+			fmt.Fprintf(&builder, "  hardware ethernet %s;\n", host.mac)
+			fmt.Fprintf(&builder, "  fixed-address %s;\n", host.ip)
+			fmt.Fprintf(&builder, "  option host-name \"%s\";\n", host.name)
+			bootHints("  ", host.pxe)
+			builder.WriteString("}\n")
+		}
+	}
+
+	return builder.String()
+}
+
+type keaOption struct {
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+type keaPool struct {
+	Pool       string      `json:"pool"`
+	OptionData []keaOption `json:"option-data,omitempty"`
+}
+
+type keaReservation struct {
+	HWAddress    string `json:"hw-address"`
+	IPAddress    string `json:"ip-address"`
+	Hostname     string `json:"hostname"`
+	NextServer   string `json:"next-server,omitempty"`
+	BootFileName string `json:"boot-file-name,omitempty"`
+}
+
+type keaSubnet struct {
+	ID           int              `json:"id"`
+	Subnet       string           `json:"subnet"`
+	OptionData   []keaOption      `json:"option-data,omitempty"`
+	Pools        []keaPool        `json:"pools,omitempty"`
+	Reservations []keaReservation `json:"reservations,omitempty"`
+}
+
+func renderDHCPKea(subnetList []*dhcpSubnet) (string, error) {
+	keaList := []keaSubnet{}
+	for _, subnet := range subnetList {
+		item := keaSubnet{ID: subnet.id, Subnet: subnet.network.String()}
+		if subnet.gateway != nil {
+			item.OptionData = []keaOption{{Name: "routers", Data: subnet.gateway.String()}}
+		}
+		for _, pool := range subnet.poolList {
+			entry := keaPool{Pool: fmt.Sprintf("%s - %s", pool.start, pool.end)}
+			if pool.pxe != "" {
+				entry.OptionData = append(entry.OptionData, keaOption{Name: "boot-file-name", Data: dhcpBootFile(pool.pxe)})
+				if dhcpNextServer != "" {
+					entry.OptionData = append(entry.OptionData, keaOption{Name: "tftp-server-name", Data: dhcpNextServer})
+				}
+			}
+			item.Pools = append(item.Pools, entry)
+		}
+		for _, host := range subnet.hostList {
+			entry := keaReservation{HWAddress: host.mac, IPAddress: host.ip.String(), Hostname: host.name}
+			if host.pxe != "" {
+				entry.BootFileName = dhcpBootFile(host.pxe)
+				entry.NextServer = dhcpNextServer
+			}
+			item.Reservations = append(item.Reservations, entry)
+		}
+		keaList = append(keaList, item)
+	}
+
+	buff, err := json.MarshalIndent(map[string]interface{}{"Dhcp4": map[string]interface{}{"subnet4": keaList}}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(buff) + "\n", nil
+}
+
+func renderDHCPDnsmasq(subnetList []*dhcpSubnet) string {
+	var builder strings.Builder
+	builder.WriteString("# Generated by contractorcli from Contractor, do not edit\n")
+
+	pxeList := []string{}
+	pxeSeen := map[string]bool{}
+	for _, subnet := range subnetList {
+		// dnsmasq only allows one tag to be set per range, so pool tags combine the block and the PXE
+		tag := fmt.Sprintf("block%d", subnet.id)
+		tagList := []string{tag}
+		mask := net.IP(subnet.network.Mask).String()
+
+		fmt.Fprintf(&builder, "\n# %s\n", subnet.name)
+		fmt.Fprintf(&builder, "dhcp-range=set:%s,%s,static,%s\n", tag, subnet.network.IP, mask)
+		poolPxeList := []string{}
+		poolPxeSeen := map[string]bool{}
+		for _, pool := range subnet.poolList {
+			poolTag := tag
+			if pool.pxe != "" {
+				poolTag = tag + "-" + pool.pxe
+				if !poolPxeSeen[pool.pxe] {
+					poolPxeSeen[pool.pxe] = true
+					tagList = append(tagList, poolTag)
+					poolPxeList = append(poolPxeList, pool.pxe)
+				}
+			}
+			fmt.Fprintf(&builder, "dhcp-range=set:%s,%s,%s,%s\n", poolTag, pool.start, pool.end, mask)
+		}
+		if subnet.gateway != nil {
+			for _, item := range tagList {
+				fmt.Fprintf(&builder, "dhcp-option=tag:%s,option:router,%s\n", item, subnet.gateway)
+			}
+		}
+		for i, pxe := range poolPxeList {
+			fmt.Fprintf(&builder, "dhcp-boot=tag:%s,%s,,%s\n", tagList[i+1], dhcpBootFile(pxe), dhcpNextServer)
+		}
+
+		for _, host := range subnet.hostList {
+			if host.pxe == "" {
+				fmt.Fprintf(&builder, "dhcp-host=%s,%s,%s\n", host.mac, host.ip, host.name)
+				continue
+			}
+			fmt.Fprintf(&builder, "dhcp-host=%s,set:pxe-%s,%s,%s\n", host.mac, host.pxe, host.ip, host.name)
+			if !pxeSeen[host.pxe] {
+				pxeSeen[host.pxe] = true
+				pxeList = append(pxeList, host.pxe)
+			}
+		}
+	}
+
+	if len(pxeList) > 0 {
+		sort.Strings(pxeList)
+		builder.WriteString("\n")
+		for _, pxe := range pxeList {
+			fmt.Fprintf(&builder, "dhcp-boot=tag:pxe-%s,%s,,%s\n", pxe, dhcpBootFile(pxe), dhcpNextServer)
+		}
+	}
+
+	return builder.String()
+}
+
+var exportDHCPCmd = &cobra.Command{
+	Use:   "dhcp",
+	Short: "Export DHCP server config, subnets, pools and host reservations, from the Address Blocks and Structures of a Site",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if detailSite == "" {
+			return fmt.Errorf("site required")
+		}
+		if dhcpNextServer != "" && net.ParseIP(dhcpNextServer).To4() == nil {
+			return fmt.Errorf("next server '%s' is not an IPv4 address", dhcpNextServer)
+		}
+
+		ctx := cmd.Context()
+
+		siteURI, err := exportSiteURI(ctx, detailSite)
+		if err != nil {
+			return err
+		}
+
+		hostList, err := loadExportHosts(ctx, siteURI)
+		if err != nil {
+			return err
+		}
+
+		subnetList, err := loadDHCPSubnets(ctx, siteURI, hostList)
+		if err != nil {
+			return err
+		}
+
+		var content string
+		switch dhcpFormat {
+		case "isc":
+			content = renderDHCPISC(subnetList)
+		case "kea":
+			content, err = renderDHCPKea(subnetList)
+			if err != nil {
+				return err
+			}
+		case "dnsmasq":
+			content = renderDHCPDnsmasq(subnetList)
+		default:
+			return fmt.Errorf("unknown format '%s', expected isc, kea or dnsmasq", dhcpFormat)
+		}
+
+		return writeExport(exportOut, content)
+	},
+}
+
+func init() {
+	exportDHCPCmd.Flags().StringVarP(&detailSite, "site", "s", "", "Site to export")
+	exportDHCPCmd.Flags().StringVarP(&dhcpFormat, "format", "f", "isc", "Output format, one of isc, kea or dnsmasq")
+	exportDHCPCmd.Flags().StringVarP(&exportOut, "out", "o", "", "File to write to, stdout if not specified")
+	exportDHCPCmd.Flags().StringVarP(&dhcpNextServer, "next-server", "n", "", "TFTP server to send to interfaces with a PXE")
+	exportDHCPCmd.Flags().StringToStringVarP(&dhcpBootFiles, "boot-file", "b", map[string]string{}, "Boot filename for a PXE, ie: ubuntu=ubuntu.kpxe, defaults to the PXE name")
+
+	exportCmd.AddCommand(exportDHCPCmd)
+}
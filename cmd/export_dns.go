@@ -0,0 +1,296 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	contractor "github.com/t3kton/contractor_goclient"
+)
+
+type dnsRecord struct {
+	name  string
+	rtype string
+	value string
+}
+
+type dnsZone struct {
+	name       string
+	recordList []dnsRecord
+}
+
+var dnsTypeOrder = map[string]int{"SOA": 0, "NS": 1, "MX": 2}
+
+// reverseZone returns the reverse zone and the name in it for ip, IPv4 zones are /24s, IPv6 zones are /64s
+func reverseZone(ip net.IP) (string, string) {
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("%d.%d.%d.in-addr.arpa", ip4[2], ip4[1], ip4[0]), strconv.Itoa(int(ip4[3]))
+	}
+
+	ip = ip.To16()
+	nibbles := []string{}
+	for i := len(ip) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, fmt.Sprintf("%x", ip[i]&0x0f), fmt.Sprintf("%x", ip[i]>>4))
+	}
+	return strings.Join(nibbles[16:], ".") + ".ip6.arpa", strings.Join(nibbles[:16], ".")
+}
+
+func sortDNSRecords(recordList []dnsRecord) {
+	sort.SliceStable(recordList, func(i, j int) bool {
+		a, b := recordList[i], recordList[j]
+		if (a.name == "@") != (b.name == "@") {
+			return a.name == "@"
+		}
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		oa, okA := dnsTypeOrder[a.rtype]
+		ob, okB := dnsTypeOrder[b.rtype]
+		if !okA {
+			oa = 10
+		}
+		if !okB {
+			ob = 10
+		}
+		if oa != ob {
+			return oa < ob
+		}
+		if a.rtype != b.rtype {
+			return a.rtype < b.rtype
+		}
+		return a.value < b.value
+	})
+}
+
+// renderDNSZone returns zone in BIND format, baseZone is the forward zone, used for the hostmaster of the SOA
+func renderDNSZone(zone *dnsZone, soa *contractor.DirectoryZone, nsList []string, baseZone string) (string, error) {
+	if len(nsList) == 0 {
+		return "", fmt.Errorf("name servers required, use --ns")
+	}
+
+	ttl, refresh, retry, expire, minimum := 3600, 10800, 3600, 604800, 3600
+	if soa != nil {
+		for _, v := range []struct {
+			target *int
+			value  *int
+		}{{&ttl, soa.TTL}, {&refresh, soa.Refresh}, {&retry, soa.Retry}, {&expire, soa.Expire}, {&minimum, soa.Minimum}} {
+			if v.value != nil && *v.value != 0 {
+				*v.target = *v.value
+			}
+		}
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "; Zone %s, generated by contractorcli from Contractor, do not edit\n", zone.name)
+	fmt.Fprintf(&builder, "$ORIGIN %s.\n", zone.name)
+	fmt.Fprintf(&builder, "$TTL %d\n", ttl)
+
+	fmt.Fprintf(&builder, "@ IN SOA %s hostmaster.%s. ( %d %d %d %d %d )\n", nsList[0], baseZone, dnsSerial, refresh, retry, expire, minimum)
+	for _, ns := range nsList {
+		fmt.Fprintf(&builder, "@ IN NS %s\n", ns)
+	}
+
+	width := 1
+	for _, record := range zone.recordList {
+		if len(record.name) > width {
+			width = len(record.name)
+		}
+	}
+
+	sortDNSRecords(zone.recordList)
+	for _, record := range zone.recordList {
+		fmt.Fprintf(&builder, "%-*s IN %-5s %s\n", width, record.name, record.rtype, record.value)
+	}
+
+	return builder.String(), nil
+}
+
+func dnsAbsolute(name string, zone string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "." + zone + "."
+}
+
+var exportDNSCmd = &cobra.Command{
+	Use:   "dns",
+	Short: "Export BIND zone files, forward and reverse, from the Structures and Addresses of a Site",
+	Long: `Export BIND zone files, forward and reverse, from the Structures and Addresses of a Site.
+
+The SOA serial is not generated, so the output only changes when the data does. The
+serial must be increased with --serial each time the zone files change, otherwise
+secondary name servers will not pick up the changes.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if detailSite == "" {
+			return fmt.Errorf("site required")
+		}
+
+		ctx := cmd.Context()
+
+		site, err := contractorClient.SiteSiteGet(ctx, detailSite)
+		if err != nil {
+			return err
+		}
+
+		var soa *contractor.DirectoryZone
+		if site.Zone != nil && *site.Zone != "" {
+			soa, err = contractorClient.DirectoryZoneGetURI(ctx, *site.Zone)
+			if err != nil {
+				return err
+			}
+		}
+
+		zoneName := strings.TrimSuffix(exportZone, ".")
+		if zoneName == "" {
+			if soa == nil {
+				return fmt.Errorf("site '%s' does not have a zone, zone required", detailSite)
+			}
+			zoneName = strings.TrimSuffix(stringValue(soa.Fqdn), ".")
+		} else if soa != nil && strings.TrimSuffix(stringValue(soa.Fqdn), ".") != zoneName {
+			soa = nil // the SOA values and entries are for a different zone
+		}
+
+		nsList := []string{}
+		for _, ns := range exportNSList {
+			nsList = append(nsList, dnsAbsolute(ns, zoneName))
+		}
+
+		forward := &dnsZone{name: zoneName}
+		if soa != nil {
+			vchan, err := contractorClient.DirectoryEntryList(ctx, "zone", map[string]interface{}{"zone": soa.GetURI()})
+			if err != nil {
+				return err
+			}
+			for v := range vchan {
+				name := stringValue(v.Name)
+				if name == "" {
+					name = "@"
+				}
+				rtype := strings.ToUpper(stringValue(v.Type))
+				target := stringValue(v.Target)
+				value := target
+				switch rtype {
+				case "NS":
+					if name == "@" && len(exportNSList) == 0 {
+						nsList = append(nsList, dnsAbsolute(target, zoneName))
+						continue
+					}
+					value = dnsAbsolute(target, zoneName)
+				case "CNAME", "PTR":
+					value = dnsAbsolute(target, zoneName)
+				case "MX":
+					value = fmt.Sprintf("%d %s", intValue(v.Priority), dnsAbsolute(target, zoneName))
+				case "SRV":
+					value = fmt.Sprintf("%d %d %d %s", intValue(v.Priority), intValue(v.Weight), intValue(v.Port), dnsAbsolute(target, zoneName))
+				case "TXT":
+					value = strconv.Quote(target)
+				}
+				forward.recordList = append(forward.recordList, dnsRecord{name, rtype, value})
+			}
+		}
+		sort.Strings(nsList)
+
+		hostList, err := loadExportHosts(ctx, site.GetURI())
+		if err != nil {
+			return err
+		}
+
+		reverseMap := map[string]*dnsZone{}
+		for _, host := range hostList {
+			if host.Hostname == "" {
+				continue
+			}
+			for _, address := range host.AddressList {
+				name := addressName(host, address)
+				rtype := "A"
+				if address.IP.To4() == nil {
+					rtype = "AAAA"
+				}
+				forward.recordList = append(forward.recordList, dnsRecord{name, rtype, address.IP.String()})
+
+				revZone, revName := reverseZone(address.IP)
+				if reverseMap[revZone] == nil {
+					reverseMap[revZone] = &dnsZone{name: revZone}
+				}
+				reverseMap[revZone].recordList = append(reverseMap[revZone].recordList, dnsRecord{revName, "PTR", dnsAbsolute(name, zoneName)})
+			}
+		}
+
+		zoneList := []*dnsZone{forward}
+		reverseNames := []string{}
+		for name := range reverseMap {
+			reverseNames = append(reverseNames, name)
+		}
+		sort.Strings(reverseNames)
+		for _, name := range reverseNames {
+			zoneList = append(zoneList, reverseMap[name])
+		}
+
+		if exportOut != "" && exportOut != "-" {
+			if err := os.MkdirAll(exportOut, 0755); err != nil {
+				return err
+			}
+		}
+
+		for i, zone := range zoneList {
+			content, err := renderDNSZone(zone, soa, nsList, zoneName)
+			if err != nil {
+				return err
+			}
+
+			if exportOut == "" || exportOut == "-" {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Print(content)
+				continue
+			}
+
+			filename := filepath.Join(exportOut, zone.name+".zone")
+			if err := writeFileAtomic(filename, []byte(content), 0644); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Wrote %s\n", filename)
+		}
+
+		return nil
+	},
+}
+
+func intValue(value *int) int {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+func init() {
+	exportDNSCmd.Flags().StringVarP(&detailSite, "site", "s", "", "Site to export")
+	exportDNSCmd.Flags().StringVarP(&exportZone, "zone", "z", "", "Forward zone name, ie: example.com, defaults to the zone of the site")
+	exportDNSCmd.Flags().StringSliceVarP(&exportNSList, "ns", "n", []string{}, "Name Servers for the NS records, defaults to the NS entries of the site's zone")
+	exportDNSCmd.Flags().IntVarP(&dnsSerial, "serial", "e", 1, "SOA serial number, must be increased each time the zone changes")
+	exportDNSCmd.Flags().StringVarP(&exportOut, "out", "o", "", "Directory to write the zone files to, named <zone>.zone, stdout if not specified")
+
+	exportCmd.AddCommand(exportDNSCmd)
+}
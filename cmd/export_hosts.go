@@ -0,0 +1,212 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+)
+
+const managedBegin = "# BEGIN contractorcli managed block"
+const managedEnd = "# END contractorcli managed block"
+
+// replaceManagedBlock returns content with the lines between the begin and end markers replaced by block,
+// if there are no markers the block is appended
+func replaceManagedBlock(content string, block string) (string, error) {
+	managed := managedBegin + "\n" + block + managedEnd + "\n"
+
+	lineList := strings.SplitAfter(content, "\n")
+	begin, end := -1, -1
+	for i, line := range lineList {
+		switch strings.TrimSpace(line) {
+		case managedBegin:
+			if begin != -1 {
+				return "", fmt.Errorf("more than one '%s' line", managedBegin)
+			}
+			begin = i
+		case managedEnd:
+			if begin == -1 || end != -1 {
+				return "", fmt.Errorf("unexpected '%s' line", managedEnd)
+			}
+			end = i
+		}
+	}
+
+	if begin == -1 {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		return content + managed, nil
+	}
+	if end == -1 {
+		return "", fmt.Errorf("'%s' without a '%s' line", managedBegin, managedEnd)
+	}
+
+	return strings.Join(lineList[:begin], "") + managed + strings.Join(lineList[end+1:], ""), nil
+}
+
+// writeManagedBlock updates the managed block in filename, creating it with perm if it does not exist, stdout if
+// filename is "" or "-".  If check is set, the file is not changed, and an error is returned if it is stale.
+func writeManagedBlock(filename string, block string, perm os.FileMode, check bool) error {
+	if filename == "" || filename == "-" {
+		if check {
+			return fmt.Errorf("file required to check")
+		}
+		fmt.Print(managedBegin + "\n" + block + managedEnd + "\n")
+		return nil
+	}
+
+	filename, err := homedir.Expand(filename)
+	if err != nil {
+		return err
+	}
+
+	current := ""
+	buff, err := os.ReadFile(filename)
+	if err == nil {
+		current = string(buff)
+		// update the target of a symlink, not replace the link
+		filename, err = filepath.EvalSymlinks(filename)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		perm = info.Mode().Perm()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	content, err := replaceManagedBlock(current, block)
+	if err != nil {
+		return fmt.Errorf("%s: %s", filename, err)
+	}
+
+	if check {
+		if content != current {
+			return fmt.Errorf("%s is stale", filename)
+		}
+		fmt.Printf("%s is up to date\n", filename)
+		return nil
+	}
+
+	if content == current {
+		return nil
+	}
+
+	return writeFileAtomic(filename, []byte(content), perm)
+}
+
+// loadManagedHosts returns the structures with a hostname and a primary address, limited to the
+// --site and --blueprint if specified
+func loadManagedHosts(cmd *cobra.Command) ([]*exportHost, error) {
+	ctx := cmd.Context()
+
+	siteURI, err := exportSiteURI(ctx, detailSite)
+	if err != nil {
+		return nil, err
+	}
+
+	hostList, err := loadExportHosts(ctx, siteURI)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*exportHost{}
+	for _, host := range hostList {
+		if host.Hostname == "" || host.PrimaryAddress() == nil {
+			continue
+		}
+		if detailBlueprint != "" && host.Blueprint != detailBlueprint {
+			continue
+		}
+		result = append(result, host)
+	}
+
+	return result, nil
+}
+
+var exportHostsCmd = &cobra.Command{
+	Use:   "hosts",
+	Short: "Export the hostnames and primary addresses of Structures to a managed block in a hosts file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hostList, err := loadManagedHosts(cmd)
+		if err != nil {
+			return err
+		}
+
+		var builder strings.Builder
+		for _, host := range hostList {
+			names := host.Hostname
+			if managedDomain != "" {
+				names = host.Hostname + "." + strings.Trim(managedDomain, ".") + " " + host.Hostname
+			}
+			fmt.Fprintf(&builder, "%s\t%s\n", host.PrimaryAddress().IP, names)
+		}
+
+		return writeManagedBlock(managedFile, builder.String(), 0644, managedCheck)
+	},
+}
+
+var exportSSHConfigCmd = &cobra.Command{
+	Use:   "ssh-config",
+	Short: "Export the hostnames and primary addresses of Structures to a managed block in a SSH config file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hostList, err := loadManagedHosts(cmd)
+		if err != nil {
+			return err
+		}
+
+		var builder strings.Builder
+		for _, host := range hostList {
+			fmt.Fprintf(&builder, "Host %s\n", host.Hostname)
+			fmt.Fprintf(&builder, "    HostName %s\n", host.PrimaryAddress().IP)
+			if managedUser != "" {
+				fmt.Fprintf(&builder, "    User %s\n", managedUser)
+			}
+		}
+
+		// ssh refuses config files others can write to, so new files are only accessible by the user
+		return writeManagedBlock(managedFile, builder.String(), 0600, managedCheck)
+	},
+}
+
+func init() {
+	exportHostsCmd.Flags().StringVarP(&detailSite, "site", "s", "", "Only include the Structures of this Site")
+	exportHostsCmd.Flags().StringVarP(&detailBlueprint, "blueprint", "b", "", "Only include the Structures of this Blueprint")
+	exportHostsCmd.Flags().StringVarP(&managedFile, "file", "f", "", "Hosts file to update the managed block of, ie: /etc/hosts, stdout if not specified")
+	exportHostsCmd.Flags().BoolVarP(&managedCheck, "check", "c", false, "Do not update the file, exit with an error if it is stale")
+	exportHostsCmd.Flags().StringVarP(&managedDomain, "domain", "d", "", "Domain to also add the hostname with, ie: example.com")
+
+	exportSSHConfigCmd.Flags().StringVarP(&detailSite, "site", "s", "", "Only include the Structures of this Site")
+	exportSSHConfigCmd.Flags().StringVarP(&detailBlueprint, "blueprint", "b", "", "Only include the Structures of this Blueprint")
+	exportSSHConfigCmd.Flags().StringVarP(&managedFile, "file", "f", "", "SSH config file to update the managed block of, ie: ~/.ssh/config, stdout if not specified")
+	exportSSHConfigCmd.Flags().BoolVarP(&managedCheck, "check", "c", false, "Do not update the file, exit with an error if it is stale")
+	exportSSHConfigCmd.Flags().StringVarP(&managedUser, "user", "u", "", "User to login as")
+
+	exportCmd.AddCommand(exportHostsCmd)
+	exportCmd.AddCommand(exportSSHConfigCmd)
+}
@@ -0,0 +1,221 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type exportTarget struct {
+	Hostname       string   `json:"hostname"`
+	Address        string   `json:"address"`
+	Ports          []int    `json:"ports"`
+	Site           string   `json:"site"`
+	Blueprint      string   `json:"blueprint"`
+	FoundationType string   `json:"foundation_type"`
+	Complexes      []string `json:"complexes"`
+}
+
+// Targets returns the address of the target with each of its ports, or just the address if there are no ports
+func (t *exportTarget) Targets() []string {
+	if len(t.Ports) == 0 {
+		return []string{t.Address}
+	}
+	result := []string{}
+	for _, port := range t.Ports {
+		result = append(result, net.JoinHostPort(t.Address, strconv.Itoa(port)))
+	}
+	return result
+}
+
+// parsePortMap parses a list of "<blueprint>=<port>[,<port>...]" into the ports by blueprint
+func parsePortMap(valueList []string) (map[string][]int, error) {
+	result := map[string][]int{}
+	for _, value := range valueList {
+		blueprint, ports, ok := strings.Cut(value, "=")
+		if !ok || blueprint == "" {
+			return nil, fmt.Errorf("invalid port mapping '%s', expected <blueprint>=<port>[,<port>...]", value)
+		}
+		for _, item := range strings.Split(ports, ",") {
+			port, err := strconv.Atoi(strings.TrimSpace(item))
+			if err != nil || port < 1 || port > 65535 {
+				return nil, fmt.Errorf("invalid port '%s' for blueprint '%s'", item, blueprint)
+			}
+			result[blueprint] = append(result[blueprint], port)
+		}
+	}
+	return result, nil
+}
+
+func renderTargetsFileSD(targetList []*exportTarget) (string, error) {
+	type fileSDGroup struct {
+		Targets []string          `json:"targets"`
+		Labels  map[string]string `json:"labels"`
+	}
+
+	groupList := []fileSDGroup{}
+	for _, target := range targetList {
+		groupList = append(groupList, fileSDGroup{
+			Targets: target.Targets(),
+			Labels: map[string]string{
+				"hostname":        target.Hostname,
+				"site":            target.Site,
+				"blueprint":       target.Blueprint,
+				"foundation_type": target.FoundationType,
+				"complex":         strings.Join(target.Complexes, ","),
+			},
+		})
+	}
+
+	buff, err := json.MarshalIndent(groupList, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(buff) + "\n", nil
+}
+
+func renderTargetsJSON(targetList []*exportTarget) (string, error) {
+	buff, err := json.MarshalIndent(targetList, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(buff) + "\n", nil
+}
+
+func renderTargetsCSV(targetList []*exportTarget) (string, error) {
+	var buff bytes.Buffer
+	writer := csv.NewWriter(&buff)
+	writer.Write([]string{"hostname", "address", "target", "site", "blueprint", "foundation_type", "complex"})
+	for _, target := range targetList {
+		for _, item := range target.Targets() {
+			writer.Write([]string{target.Hostname, target.Address, item, target.Site, target.Blueprint, target.FoundationType, strings.Join(target.Complexes, ",")})
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+	return buff.String(), nil
+}
+
+var exportTargetsCmd = &cobra.Command{
+	Use:   "targets",
+	Short: "Export the built Structures as monitoring targets",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		portMap, err := parsePortMap(targetsPorts)
+		if err != nil {
+			return err
+		}
+
+		var render func([]*exportTarget) (string, error)
+		switch targetsFormat {
+		case "prometheus-file-sd":
+			render = renderTargetsFileSD
+		case "json":
+			render = renderTargetsJSON
+		case "csv":
+			render = renderTargetsCSV
+		default:
+			return fmt.Errorf("unknown format '%s', expected prometheus-file-sd, json or csv", targetsFormat)
+		}
+
+		ctx := cmd.Context()
+
+		siteURI, err := exportSiteURI(ctx, detailSite)
+		if err != nil {
+			return err
+		}
+
+		hostList, err := loadExportHosts(ctx, siteURI)
+		if err != nil {
+			return err
+		}
+
+		complexMap, err := loadStructureComplexes(ctx)
+		if err != nil {
+			return err
+		}
+
+		filterName := ""
+		filterValues := map[string]interface{}{}
+		if siteURI != "" {
+			filterName = "site"
+			filterValues["site"] = siteURI
+		}
+		foundationTypes := map[string]string{} // by locator
+		fchan, err := contractorClient.BuildingFoundationList(ctx, filterName, filterValues)
+		if err != nil {
+			return err
+		}
+		for v := range fchan {
+			foundationTypes[stringValue(v.Locator)] = stringValue(v.Type)
+		}
+
+		targetList := []*exportTarget{}
+		for _, host := range hostList {
+			if host.State != "built" {
+				continue
+			}
+			address := host.PrimaryAddress()
+			if address == nil {
+				fmt.Fprintf(os.Stderr, "Structure %d(%s) does not have a primary address, skipped\n", host.StructureID, host.Hostname)
+				continue
+			}
+			target := &exportTarget{
+				Hostname:       host.Hostname,
+				Address:        address.IP.String(),
+				Ports:          portMap[host.Blueprint],
+				Site:           host.Site,
+				Blueprint:      host.Blueprint,
+				FoundationType: foundationTypes[host.Foundation],
+				Complexes:      complexMap[host.StructureID],
+			}
+			if target.Ports == nil {
+				target.Ports = []int{}
+			}
+			if target.Complexes == nil {
+				target.Complexes = []string{}
+			}
+			targetList = append(targetList, target)
+		}
+
+		content, err := render(targetList)
+		if err != nil {
+			return err
+		}
+
+		return writeExport(exportOut, content)
+	},
+}
+
+func init() {
+	exportTargetsCmd.Flags().StringVarP(&detailSite, "site", "s", "", "Only include the Structures of this Site")
+	exportTargetsCmd.Flags().StringVarP(&targetsFormat, "format", "f", "prometheus-file-sd", "Output format, one of prometheus-file-sd, json or csv")
+	exportTargetsCmd.Flags().StringArrayVarP(&targetsPorts, "ports", "p", []string{}, "Ports for the Structures of a Blueprint, ie: ubuntu-focal-base=9100,9256, can be repeated")
+	exportTargetsCmd.Flags().StringVarP(&exportOut, "out", "o", "", "File to write to, replaced atomically, stdout if not specified")
+
+	exportCmd.AddCommand(exportTargetsCmd)
+}
@@ -0,0 +1,259 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const inventoryConfigWorkers = 8
+
+var ansibleGroupInvalid = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+type ansibleGroup struct {
+	Hosts []string `json:"hosts"`
+}
+
+// ansibleGroupName returns a group name that is a valid Ansible identifier
+func ansibleGroupName(prefix string, name string) string {
+	return prefix + "_" + ansibleGroupInvalid.ReplaceAllString(name, "_")
+}
+
+// buildAnsibleInventory returns the Ansible dynamic inventory, in the --list format, of the structures,
+// optionally limited to the site at siteURI
+func buildAnsibleInventory(ctx context.Context, siteURI string, withConfig bool) ([]byte, error) {
+	hostList, err := loadExportHosts(ctx, siteURI)
+	if err != nil {
+		return nil, err
+	}
+
+	complexMap, err := loadStructureComplexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	namedList := []*exportHost{}
+	for _, host := range hostList {
+		if host.Hostname != "" {
+			namedList = append(namedList, host)
+		}
+	}
+
+	configList := make([]map[string]interface{}, len(namedList))
+	if withConfig {
+		errorList := make([]error, len(namedList))
+		var wg sync.WaitGroup
+		queue := make(chan int)
+		for i := 0; i < inventoryConfigWorkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for index := range queue {
+					config, err := contractorClient.BuildingStructureNewWithID(namedList[index].StructureID).CallGetConfig(ctx)
+					if err != nil {
+						errorList[index] = fmt.Errorf("error getting the config of Structure %d: %s", namedList[index].StructureID, err)
+						continue
+					}
+					configList[index] = config
+				}
+			}()
+		}
+		for index := range namedList {
+			queue <- index
+		}
+		close(queue)
+		wg.Wait()
+
+		for _, err := range errorList {
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	groupMap := map[string]*ansibleGroup{}
+	addToGroup := func(name string, hostname string) {
+		if groupMap[name] == nil {
+			groupMap[name] = &ansibleGroup{Hosts: []string{}}
+		}
+		groupMap[name].Hosts = append(groupMap[name].Hosts, hostname)
+	}
+
+	hostVars := map[string]map[string]interface{}{}
+	for index, host := range namedList {
+		complexList := complexMap[host.StructureID]
+
+		vars := map[string]interface{}{
+			"contractor_structure_id": host.StructureID,
+			"contractor_site":         host.Site,
+			"contractor_blueprint":    host.Blueprint,
+			"contractor_foundation":   host.Foundation,
+			"contractor_state":        host.State,
+			"contractor_complexes":    append([]string{}, complexList...),
+		}
+		if address := host.PrimaryAddress(); address != nil {
+			vars["ansible_host"] = address.IP.String()
+		}
+		if withConfig {
+			vars["contractor_config"] = configList[index]
+		}
+		hostVars[host.Hostname] = vars
+
+		addToGroup(ansibleGroupName("site", host.Site), host.Hostname)
+		addToGroup(ansibleGroupName("blueprint", host.Blueprint), host.Hostname)
+		addToGroup(ansibleGroupName("state", host.State), host.Hostname)
+		for _, name := range complexList {
+			addToGroup(ansibleGroupName("complex", name), host.Hostname)
+		}
+	}
+
+	result := map[string]interface{}{"_meta": map[string]interface{}{"hostvars": hostVars}}
+	for name, group := range groupMap {
+		result[name] = group
+	}
+
+	return json.MarshalIndent(result, "", " ")
+}
+
+// ansibleCacheFile returns the filename the inventory is cached in, it is unique to the contractor host and the options
+func ansibleCacheFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	key := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%t", configString("host"), detailSite, inventoryWithConfig)))
+	return filepath.Join(dir, "contractorcli", fmt.Sprintf("ansible-%x.json", key[:8])), nil
+}
+
+// loadAnsibleInventory returns the inventory from the cache if it is newer than the TTL, otherwise
+// connects to contractor, builds it and updates the cache
+func loadAnsibleInventory(cmd *cobra.Command) ([]byte, error) {
+	cacheFile, err := ansibleCacheFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if !inventoryRefresh && inventoryCacheTTL > 0 {
+		if info, err := os.Stat(cacheFile); err == nil && time.Since(info.ModTime()) < inventoryCacheTTL {
+			buff, err := os.ReadFile(cacheFile)
+			if err == nil {
+				return buff, nil
+			}
+		}
+	}
+
+	if err := doConnect(cmd); err != nil {
+		return nil, err
+	}
+
+	ctx := cmd.Context()
+	siteURI, err := exportSiteURI(ctx, detailSite)
+	if err != nil {
+		return nil, err
+	}
+
+	buff, err := buildAnsibleInventory(ctx, siteURI, inventoryWithConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if inventoryCacheTTL > 0 {
+		// the config can hold secrets, so the cache is only readable by the user
+		if err := os.MkdirAll(filepath.Dir(cacheFile), 0700); err != nil {
+			return nil, err
+		}
+		if err := writeFileAtomic(cacheFile, buff, 0600); err != nil {
+			return nil, err
+		}
+	}
+
+	return buff, nil
+}
+
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "Inventory for Configuration Management tools",
+}
+
+var inventoryAnsibleCmd = &cobra.Command{
+	Use:   "ansible",
+	Short: "Ansible dynamic inventory",
+	Long: `Ansible dynamic inventory of the Structures, grouped by site, blueprint, complex and state.
+
+Ansible runs inventory scripts with only --list or --host, so use a small wrapper
+script as the inventory, ie:
+
+  #!/bin/sh
+  exec contractorcli inventory ansible --site site1 --with-config "$@"`,
+	Annotations: map[string]string{"offline": "true"}, // only connects if the cache is stale
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if inventoryList == (inventoryHost != "") {
+			return fmt.Errorf("one of --list or --host required")
+		}
+
+		buff, err := loadAnsibleInventory(cmd)
+		if err != nil {
+			return err
+		}
+
+		if inventoryList {
+			os.Stdout.Write(buff)
+			os.Stdout.Write([]byte("\n"))
+			return nil
+		}
+
+		inventory := struct {
+			Meta struct {
+				HostVars map[string]json.RawMessage `json:"hostvars"`
+			} `json:"_meta"`
+		}{}
+		if err := json.Unmarshal(buff, &inventory); err != nil {
+			return err
+		}
+
+		vars, ok := inventory.Meta.HostVars[inventoryHost]
+		if !ok {
+			vars = json.RawMessage("{}")
+		}
+		os.Stdout.Write(vars)
+		os.Stdout.Write([]byte("\n"))
+
+		return nil
+	},
+}
+
+func init() {
+	inventoryAnsibleCmd.Flags().BoolVarP(&inventoryList, "list", "", false, "Output the whole inventory")
+	inventoryAnsibleCmd.Flags().StringVarP(&inventoryHost, "host", "", "", "Output the variables of a host")
+	inventoryAnsibleCmd.Flags().StringVarP(&detailSite, "site", "s", "", "Only include the Structures of this Site")
+	inventoryAnsibleCmd.Flags().BoolVarP(&inventoryWithConfig, "with-config", "c", false, "Include the compiled config of each Structure as contractor_config")
+	inventoryAnsibleCmd.Flags().DurationVarP(&inventoryCacheTTL, "cache-ttl", "t", 5*time.Minute, "How long to use the cached inventory for, 0 to disable the cache")
+	inventoryAnsibleCmd.Flags().BoolVarP(&inventoryRefresh, "refresh", "r", false, "Ignore the cached inventory and rebuild it")
+
+	rootCmd.AddCommand(inventoryCmd)
+	inventoryCmd.AddCommand(inventoryAnsibleCmd)
+}
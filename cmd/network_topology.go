@@ -0,0 +1,291 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type topologyBlock struct {
+	ID     string
+	Name   string
+	Subnet string
+	Vlan   int
+}
+
+type topologyInterface struct {
+	Owner string // foundation locator or structure hostname
+	Name  string
+	Type  string
+	Mac   string
+}
+
+type topologyNetwork struct {
+	ID             string
+	Name           string
+	Site           string
+	MTU            int
+	BlockList      []*topologyBlock
+	FoundationList []*topologyInterface
+	StructureList  []*topologyInterface
+}
+
+// loadTopology returns the networks, optionally limited to the site at siteURI, with their address blocks
+// and the interfaces attached to them, sorted by name
+func loadTopology(ctx context.Context, siteURI string) ([]*topologyNetwork, error) {
+	filterName := ""
+	filterValues := map[string]interface{}{}
+	if siteURI != "" {
+		filterName = "site"
+		filterValues["site"] = siteURI
+	}
+
+	networkMap := map[string]*topologyNetwork{} // by uri
+	networkList := []*topologyNetwork{}
+	nchan, err := contractorClient.UtilitiesNetworkList(ctx, filterName, filterValues)
+	if err != nil {
+		return nil, err
+	}
+	for v := range nchan {
+		network := &topologyNetwork{
+			ID:   extractID(v.GetURI()),
+			Name: stringValue(v.Name),
+			Site: extractID(stringValue(v.Site)),
+			MTU:  intValue(v.Mtu),
+		}
+		networkMap[v.GetURI()] = network
+		networkList = append(networkList, network)
+	}
+
+	blockMap := map[string]*topologyBlock{} // by uri, without the vlan
+	bchan, err := contractorClient.UtilitiesAddressBlockList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for v := range bchan {
+		block := &topologyBlock{ID: extractID(v.GetURI()), Name: stringValue(v.Name)}
+		if v.Subnet != nil && v.Prefix != nil {
+			block.Subnet = fmt.Sprintf("%s/%d", formatIP(*v.Subnet), *v.Prefix)
+		}
+		blockMap[v.GetURI()] = block
+	}
+
+	lchan, err := contractorClient.UtilitiesNetworkAddressBlockList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for v := range lchan {
+		network, ok := networkMap[stringValue(v.Network)]
+		if !ok {
+			continue
+		}
+		block := topologyBlock{ID: extractID(stringValue(v.AddressBlock))}
+		if item, ok := blockMap[stringValue(v.AddressBlock)]; ok {
+			block = *item
+		}
+		block.Vlan = intValue(v.Vlan)
+		network.BlockList = append(network.BlockList, &block)
+	}
+
+	hostnameMap := map[string]string{} // by structure uri
+	schan, err := contractorClient.BuildingStructureList(ctx, filterName, filterValues)
+	if err != nil {
+		return nil, err
+	}
+	for v := range schan {
+		hostnameMap[v.GetURI()] = stringValue(v.Hostname)
+	}
+
+	ichan, err := contractorClient.UtilitiesRealNetworkInterfaceList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for v := range ichan {
+		network, ok := networkMap[stringValue(v.Network)]
+		if !ok {
+			continue
+		}
+		network.FoundationList = append(network.FoundationList, &topologyInterface{
+			Owner: extractID(stringValue(v.Foundation)),
+			Name:  stringValue(v.Name),
+			Type:  stringValue(v.Type),
+			Mac:   normalizeMac(stringValue(v.Mac)),
+		})
+	}
+
+	achan, err := contractorClient.UtilitiesAbstractNetworkInterfaceList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for v := range achan {
+		network, ok := networkMap[stringValue(v.Network)]
+		if !ok {
+			continue
+		}
+		owner, ok := hostnameMap[stringValue(v.Structure)]
+		if !ok || owner == "" {
+			owner = "Structure " + extractID(stringValue(v.Structure))
+		}
+		network.StructureList = append(network.StructureList, &topologyInterface{
+			Owner: owner,
+			Name:  stringValue(v.Name),
+			Type:  stringValue(v.Type),
+		})
+	}
+
+	sortInterfaces := func(interfaceList []*topologyInterface) {
+		sort.SliceStable(interfaceList, func(i, j int) bool {
+			if interfaceList[i].Owner != interfaceList[j].Owner {
+				return interfaceList[i].Owner < interfaceList[j].Owner
+			}
+			return interfaceList[i].Name < interfaceList[j].Name
+		})
+	}
+	for _, network := range networkList {
+		sort.SliceStable(network.BlockList, func(i, j int) bool {
+			if network.BlockList[i].Vlan != network.BlockList[j].Vlan {
+				return network.BlockList[i].Vlan < network.BlockList[j].Vlan
+			}
+			return network.BlockList[i].Subnet < network.BlockList[j].Subnet
+		})
+		sortInterfaces(network.FoundationList)
+		sortInterfaces(network.StructureList)
+	}
+
+	sort.SliceStable(networkList, func(i, j int) bool {
+		if networkList[i].Name != networkList[j].Name {
+			return networkList[i].Name < networkList[j].Name
+		}
+		return networkList[i].ID < networkList[j].ID
+	})
+
+	return networkList, nil
+}
+
+func vlanLabel(vlan int) string {
+	if vlan == 0 {
+		return "untagged"
+	}
+	return fmt.Sprintf("vlan %d", vlan)
+}
+
+func topologyText(networkList []*topologyNetwork) string {
+	var builder strings.Builder
+
+	for i, network := range networkList {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		fmt.Fprintf(&builder, "%s(%s) site: %s mtu: %d\n", network.Name, network.ID, network.Site, network.MTU)
+
+		builder.WriteString("  Address Blocks:\n")
+		for _, block := range network.BlockList {
+			fmt.Fprintf(&builder, "    %s(%s) %s %s\n", block.Name, block.ID, block.Subnet, vlanLabel(block.Vlan))
+		}
+
+		builder.WriteString("  Foundation Interfaces:\n")
+		for _, iface := range network.FoundationList {
+			fmt.Fprintf(&builder, "    %s:%s %s %s\n", iface.Owner, iface.Name, iface.Type, iface.Mac)
+		}
+
+		builder.WriteString("  Structure Interfaces:\n")
+		for _, iface := range network.StructureList {
+			fmt.Fprintf(&builder, "    %s:%s %s\n", iface.Owner, iface.Name, iface.Type)
+		}
+	}
+
+	return builder.String()
+}
+
+func topologyDot(networkList []*topologyNetwork) string {
+	var builder strings.Builder
+	builder.WriteString("graph topology {\n")
+	builder.WriteString("  rankdir=LR;\n")
+
+	nodeSeen := map[string]bool{}
+	node := func(id string, label string, shape string) {
+		if nodeSeen[id] {
+			return
+		}
+		nodeSeen[id] = true
+		fmt.Fprintf(&builder, "  %q [label=%q, shape=%s];\n", id, label, shape)
+	}
+
+	for _, network := range networkList {
+		networkNode := "network:" + network.ID
+		node(networkNode, fmt.Sprintf("%s\nmtu %d", network.Name, network.MTU), "box")
+
+		for _, block := range network.BlockList {
+			blockNode := "block:" + block.ID
+			node(blockNode, fmt.Sprintf("%s\n%s", block.Name, block.Subnet), "note")
+			fmt.Fprintf(&builder, "  %q -- %q [label=%q];\n", networkNode, blockNode, vlanLabel(block.Vlan))
+		}
+		for _, iface := range network.FoundationList {
+			foundationNode := "foundation:" + iface.Owner
+			node(foundationNode, iface.Owner, "component")
+			fmt.Fprintf(&builder, "  %q -- %q [label=%q];\n", foundationNode, networkNode, iface.Name)
+		}
+		for _, iface := range network.StructureList {
+			structureNode := "structure:" + iface.Owner
+			node(structureNode, iface.Owner, "ellipse")
+			fmt.Fprintf(&builder, "  %q -- %q [label=%q, style=dashed];\n", structureNode, networkNode, iface.Name)
+		}
+	}
+	builder.WriteString("}\n")
+
+	return builder.String()
+}
+
+var networkTopologyCmd = &cobra.Command{
+	Use:   "topology",
+	Short: "Show the Networks with their Address Blocks and the Interfaces attached to them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		siteURI, err := exportSiteURI(ctx, detailSite)
+		if err != nil {
+			return err
+		}
+
+		networkList, err := loadTopology(ctx, siteURI)
+		if err != nil {
+			return err
+		}
+
+		if asJSON {
+			outputDetail(networkList, "")
+		} else if detailDOT {
+			fmt.Print(topologyDot(networkList))
+		} else {
+			fmt.Print(topologyText(networkList))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	networkTopologyCmd.Flags().StringVarP(&detailSite, "site", "s", "", "Only include the Networks of this Site")
+	networkTopologyCmd.Flags().BoolVarP(&detailDOT, "dot", "", false, "Output in Graphviz DOT format")
+
+	networkCmd.AddCommand(networkTopologyCmd)
+}
@@ -0,0 +1,427 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	cinp "github.com/cinp/go"
+	"github.com/spf13/cobra"
+	contractor "github.com/t3kton/contractor_goclient"
+)
+
+const addressDetailTemplate = `Id:            {{.GetURI | extractID}}
+AddressBlock:  {{if .AddressBlock}}{{.AddressBlock | extractID}}{{end}}
+Offset:        {{.Offset}}
+Networked:     {{if .Networked}}{{.Networked | extractID}}{{end}}
+InterfaceName: {{.InterfaceName}}
+AliasIndex:    {{.AliasIndex}}
+Pointer:       {{if .Pointer}}{{.Pointer | extractID}}{{end}}
+IsPrimary:     {{.IsPrimary}}
+Type:          {{.Type}}
+IPAddress:     {{.IPAddress}}
+Subnet:        {{.Subnet}}
+Netmask:       {{.Netmask}}
+Prefix:        {{.Prefix}}
+Created:       {{.Created}}
+Updated:       {{.Updated}}
+`
+
+var addressListHeader = []string{"Id", "Interface", "Alias", "Address", "Address Block", "Offset", "Pointer", "Is Primary", "Created", "Updated"}
+
+const addressListTemplate = "{{.GetURI | extractID}}	{{.InterfaceName}}	{{.AliasIndex}}	{{if .IPAddress}}{{.IPAddress | formatIP}}{{end}}	{{if .AddressBlock}}{{.AddressBlock | extractID}}{{end}}	{{.Offset}}	{{if .Pointer}}{{.Pointer | extractID}}{{end}}	{{.IsPrimary}}	{{.Created}}	{{.Updated}}\n"
+
+func networkedArgCheck(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return errors.New("requires a Networked Id argument")
+	}
+	return nil
+}
+
+func addressArgCheck(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return errors.New("requires a Address Id argument")
+	}
+	return nil
+}
+
+// networkedURI returns the URI of the Networked uri is based on, Structures share their id with their Networked
+func networkedURI(uri string) string {
+	return "/api/v1/Utilities/Networked:" + extractID(uri) + ":"
+}
+
+// outputAddressList outputs the addresses attached to networkedURI, Address can only be filtered by Structure or
+// Address Block, and pointer aliases have no Address Block, so all the addresses are listed and filtered here
+func outputAddressList(ctx context.Context, networkedURI string) error {
+	vchan, err := contractorClient.UtilitiesAddressList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	rl := []cinp.Object{}
+	for v := range vchan {
+		if stringValue(v.Networked) == networkedURI {
+			rl = append(rl, v)
+		}
+	}
+	outputList(rl, addressListHeader, addressListTemplate)
+
+	return nil
+}
+
+// setAddressAlias sets the alias index and pointer of o from --alias-index and --pointer, returns true if anything was set
+func setAddressAlias(o *contractor.UtilitiesAddress) bool {
+	changed := false
+	if detailAliasIndex != -1 {
+		o.AliasIndex = &detailAliasIndex
+		changed = true
+	}
+	if detailPointer != 0 {
+		o.Pointer = cinp.StringAddr(contractorClient.UtilitiesAddressNewWithID(detailPointer).GetURI())
+		changed = true
+	}
+	return changed
+}
+
+// nextAddress assigns the next available address in --addressblock to networkedURI
+func nextAddress(ctx context.Context, networkedURI string) error {
+	a, err := contractorClient.UtilitiesAddressBlockGet(ctx, detailAddressBlock)
+	if err != nil {
+		return err
+	}
+
+	addressURI, err := a.CallNextAddress(ctx, networkedURI, detailInterfaceName, detailIsPrimary)
+	if err != nil {
+		return err
+	}
+
+	// next address does not take the alias index and pointer, so they are set after
+	u, err := contractorClient.UtilitiesAddressGetURI(ctx, addressURI)
+	if err != nil {
+		return err
+	}
+	if setAddressAlias(u) {
+		if err := u.Update(ctx); err != nil {
+			return err
+		}
+	}
+
+	o, err := contractorClient.UtilitiesAddressGetURI(ctx, addressURI)
+	if err != nil {
+		return err
+	}
+
+	outputDetail(o, addressDetailTemplate)
+	return nil
+}
+
+// addAddress adds the address --offset of --addressblock, or an alias of --pointer, to networkedURI
+func addAddress(ctx context.Context, networkedURI string) error {
+	if detailAddressBlock == 0 && detailPointer == 0 {
+		return fmt.Errorf("addressblock or pointer required")
+	}
+
+	o := contractorClient.UtilitiesAddressNew()
+	if detailAddressBlock != 0 {
+		a, err := contractorClient.UtilitiesAddressBlockGet(ctx, detailAddressBlock)
+		if err != nil {
+			return err
+		}
+		o.AddressBlock = cinp.StringAddr(a.GetURI())
+		o.Offset = &detailOffset
+	}
+	o.Networked = &networkedURI
+	o.InterfaceName = &detailInterfaceName
+	o.IsPrimary = &detailIsPrimary
+	setAddressAlias(o)
+
+	if err := o.Create(ctx); err != nil {
+		return err
+	}
+
+	outputDetail(o, addressDetailTemplate)
+	return nil
+}
+
+func updateAddress(ctx context.Context, addressID int) error {
+	o := contractorClient.UtilitiesAddressNewWithID(addressID)
+
+	if detailOffset != 0 {
+		o.Offset = &detailOffset
+	}
+
+	if detailInterfaceName != "" {
+		o.InterfaceName = &detailInterfaceName
+	}
+
+	setAddressAlias(o)
+
+	if err := o.Update(ctx); err != nil {
+		return err
+	}
+
+	outputDetail(o, addressDetailTemplate)
+	return nil
+}
+
+func deleteAddress(ctx context.Context, addressID int) error {
+	o, err := contractorClient.UtilitiesAddressGet(ctx, addressID)
+	if err != nil {
+		return err
+	}
+	return o.Delete(ctx)
+}
+
+// addressFlags adds the flags used by nextAddress, addAddress and updateAddress to the address commands
+func addressFlags(nextCmd *cobra.Command, addCmd *cobra.Command, updateCmd *cobra.Command) {
+	nextCmd.Flags().IntVarP(&detailAddressBlock, "addressblock", "a", 0, "Address Block to get an IP From")
+	nextCmd.Flags().StringVarP(&detailInterfaceName, "interfacename", "n", "", "Name of the Interface to assigne the IP To")
+	nextCmd.Flags().BoolVarP(&detailIsPrimary, "primary", "p", false, "If this is the primary IP Address")
+	nextCmd.Flags().IntVarP(&detailAliasIndex, "alias-index", "i", -1, "Alias index of the IP Address on the Interface")
+	nextCmd.Flags().IntVarP(&detailPointer, "pointer", "r", 0, "Id of the Address this is an alias of")
+
+	addCmd.Flags().IntVarP(&detailAddressBlock, "addressblock", "a", 0, "Address Block to get an IP From")
+	addCmd.Flags().StringVarP(&detailInterfaceName, "interfacename", "n", "", "Name of the Interface to assigne the IP To")
+	addCmd.Flags().IntVarP(&detailOffset, "offset", "o", 0, "Offset inside the Address Block to use")
+	addCmd.Flags().BoolVarP(&detailIsPrimary, "primary", "p", false, "If this is the primary IP Address")
+	addCmd.Flags().IntVarP(&detailAliasIndex, "alias-index", "i", -1, "Alias index of the IP Address on the Interface")
+	addCmd.Flags().IntVarP(&detailPointer, "pointer", "r", 0, "Id of the Address this is an alias of, instead of an Address Block and Offset")
+
+	updateCmd.Flags().StringVarP(&detailInterfaceName, "interfacename", "n", "", "Name of the Interface to assigne the IP To")
+	updateCmd.Flags().IntVarP(&detailOffset, "offset", "o", 0, "Offset inside the Address Block to use")
+	updateCmd.Flags().IntVarP(&detailAliasIndex, "alias-index", "i", -1, "Update the Alias index of the IP Address")
+	updateCmd.Flags().IntVarP(&detailPointer, "pointer", "r", 0, "Update the Id of the Address this is an alias of")
+}
+
+var networkedCmd = &cobra.Command{
+	Use:   "networked",
+	Short: "Work with Networked objects, things that can have IP Addresses that are not Structures, ie: VIPs",
+}
+
+var networkedListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List Networked, including the Networked of Structures",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		rl := []cinp.Object{}
+		vchan, err := contractorClient.UtilitiesNetworkedList(ctx, "", map[string]interface{}{})
+		if err != nil {
+			return err
+		}
+		for v := range vchan {
+			rl = append(rl, v)
+		}
+		outputList(rl, []string{"Id", "Hostname", "Site"}, "{{.GetURI | extractID}}	{{.Hostname}}	{{.Site | extractID}}\n")
+
+		return nil
+	},
+}
+
+var networkedGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Get Networked",
+	Args:  networkedArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		networkedID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+
+		o, err := contractorClient.UtilitiesNetworkedGet(ctx, networkedID)
+		if err != nil {
+			return err
+		}
+		outputDetail(o, `Id:            {{.GetURI | extractID}}
+Hostname:      {{.Hostname}}
+Site:          {{.Site | extractID}}
+`)
+
+		return nil
+	},
+}
+
+var networkedCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create New Networked",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o := contractorClient.UtilitiesNetworkedNew()
+		o.Hostname = &detailHostname
+
+		ctx := cmd.Context()
+
+		r, err := contractorClient.SiteSiteGet(ctx, detailSite)
+		if err != nil {
+			return err
+		}
+		o.Site = cinp.StringAddr(r.GetURI())
+
+		if err := o.Create(ctx); err != nil {
+			return err
+		}
+
+		outputDetail(o, `Id:            {{.GetURI | extractID}}
+Hostname:      {{.Hostname}}
+Site:          {{.Site | extractID}}
+`)
+
+		return nil
+	},
+}
+
+var networkedDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete Networked",
+	Args:  networkedArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		networkedID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+
+		if _, err := contractorClient.BuildingStructureGet(ctx, networkedID); err == nil {
+			return fmt.Errorf("networked %d is a Structure, use 'structure delete'", networkedID)
+		} else if !isNotFound(err) {
+			return err
+		}
+
+		o, err := contractorClient.UtilitiesNetworkedGet(ctx, networkedID)
+		if err != nil {
+			return err
+		}
+		if err := o.Delete(ctx); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+var networkedAddressCmd = &cobra.Command{
+	Use:   "address",
+	Short: "Work with Networked Ip Addresses",
+}
+
+var networkedAddressListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all Ip Addresses attached to a Networked",
+	Args:  networkedArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		networkedID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+
+		o, err := contractorClient.UtilitiesNetworkedGet(ctx, networkedID)
+		if err != nil {
+			return err
+		}
+
+		return outputAddressList(ctx, o.GetURI())
+	},
+}
+
+var networkedAddressNextCmd = &cobra.Command{
+	Use:   "next",
+	Short: "Assign Next available IP address in Address Block to Networked",
+	Args:  networkedArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		networkedID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+
+		o, err := contractorClient.UtilitiesNetworkedGet(ctx, networkedID)
+		if err != nil {
+			return err
+		}
+
+		return nextAddress(ctx, o.GetURI())
+	},
+}
+
+var networkedAddressAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add an IP address to Networked",
+	Args:  networkedArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		networkedID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+
+		o, err := contractorClient.UtilitiesNetworkedGet(ctx, networkedID)
+		if err != nil {
+			return err
+		}
+
+		return addAddress(ctx, o.GetURI())
+	},
+}
+
+var networkedAddressUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update an IP address of a Networked",
+	Args:  addressArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addressID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		return updateAddress(cmd.Context(), addressID)
+	},
+}
+
+var networkedAddressDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete IP address from a Networked",
+	Args:  addressArgCheck,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addressID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		return deleteAddress(cmd.Context(), addressID)
+	},
+}
+
+func init() {
+	networkedCreateCmd.Flags().StringVarP(&detailHostname, "hostname", "o", "", "Hostname of New Networked")
+	networkedCreateCmd.Flags().StringVarP(&detailSite, "site", "s", "", "Site of New Networked")
+
+	addressFlags(networkedAddressNextCmd, networkedAddressAddCmd, networkedAddressUpdateCmd)
+
+	rootCmd.AddCommand(networkedCmd)
+	networkedCmd.AddCommand(networkedListCmd, networkedGetCmd, networkedCreateCmd, networkedDeleteCmd)
+
+	networkedCmd.AddCommand(networkedAddressCmd)
+	networkedAddressCmd.AddCommand(networkedAddressListCmd, networkedAddressNextCmd, networkedAddressAddCmd, networkedAddressUpdateCmd, networkedAddressDeleteCmd)
+}
@@ -17,10 +17,14 @@ limitations under the License.
 */
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"os"
 	"os/exec"
 	"strings"
@@ -35,7 +39,8 @@ import (
 	"github.com/spf13/viper"
 )
 
-var cfgFile string
+var cfgFile, contextName, activeContext string
+var contextErr error
 var asJSON, debug bool
 var version = "development"
 var gitVersion = "none"
@@ -48,13 +53,23 @@ var rootCmd = &cobra.Command{
 of contractor without having to write your own small app, or use the API`,
 	SilenceUsage:  true,
 	SilenceErrors: false,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if contextErr != nil && cmd.Annotations["context"] != "optional" {
+			return contextErr
+		}
+		if cmd.Annotations["offline"] == "true" {
+			return nil
+		}
+		return doConnect(cmd)
+	},
 }
 
 var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Show Version",
+	Use:         "version",
+	Short:       "Show Version",
+	Annotations: map[string]string{"offline": "true", "context": "optional"},
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("contractorcli\n  Version:\t%s\n  Commit:\t%s\n", version, gitVersion)
+		fmt.Printf("contractorcli\n  Version:\t%s\n  Commit:\t%s\n  Context:\t%s\n", version, gitVersion, activeContext)
 	},
 }
 
@@ -72,6 +87,7 @@ func init() {
 	cobra.OnFinalize(doFinalize)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.contractorcli.ini)")
+	rootCmd.PersistentFlags().StringVar(&contextName, "context", "", "context from the config file to use (default is the current_context of the config file)")
 	rootCmd.PersistentFlags().BoolVarP(&asJSON, "json", "j", false, "Output as JSON")
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "", false, "Debug Output(will interfere with JSON output)")
 
@@ -112,6 +128,11 @@ func doInit() {
 		//fmt.Println("Using config file:", viper.ConfigFileUsed())
 	}*/
 
+	resolveContext()
+}
+
+// doConnect logs into contractor, commands with the "offline" annotation skip this
+func doConnect(cmd *cobra.Command) error {
 	handlerOptions := &slog.HandlerOptions{}
 	if debug {
 		handlerOptions.Level = slog.LevelDebug
@@ -121,14 +142,23 @@ func doInit() {
 	handler := slog.NewTextHandler(os.Stderr, handlerOptions)
 	log := slog.New(handler)
 
-	contractorClient, err = contractor.NewContractor(rootCmd.Context(), log, viper.GetString("contractor.host"), viper.GetString("contractor.proxy"), viper.GetString("contractor.username"), viper.GetString("contractor.password"))
+	password, err := contractorPassword()
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
+	}
+
+	contractorClient, err = contractor.NewContractor(cmd.Context(), log, configString("host"), configString("proxy"), configString("username"), password)
+	if err != nil {
+		return err
 	}
+
+	return nil
 }
 
 func doFinalize() {
+	if contractorClient == nil {
+		return
+	}
 	contractorClient.Logout(rootCmd.Context())
 	contractorClient = nil
 }
@@ -140,6 +170,21 @@ func extractID(value string) string {
 	return strings.Split(value, ":")[1]
 }
 
+// formatIP returns value in the canonical form for the address family, ie: compressed for IPv6
+func formatIP(value string) string {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return value
+	}
+	return ip.String()
+}
+
+// isNotFound returns true if err is the CInP Not Found error
+func isNotFound(err error) bool {
+	var notFound *cinp.NotFound
+	return errors.As(err, &notFound)
+}
+
 func extractIDList(values []string) string {
 	if len(values) == 0 {
 		return ""
@@ -211,6 +256,34 @@ func editBuffer(value string) (string, error) {
 	return strings.TrimSpace(string(buf[:len])), nil
 }
 
+// readScriptFile reads a script/template from filename, '-' for stdin
+func readScriptFile(filename string) (string, error) {
+	var buf []byte
+	var err error
+	if filename == "-" {
+		buf, err = io.ReadAll(os.Stdin)
+	} else {
+		buf, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+// stdinReader is shared by the prompts, so answers buffered by one prompt are not lost to the next
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// askYesNo asks the user question, returns true if they answer yes
+func askYesNo(question string) bool {
+	fmt.Printf("%s(Y/N) ", question)
+	answer, _ := stdinReader.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+
+	return answer == "Y" || answer == "y"
+}
+
 func outputList(valueList []cinp.Object, header []string, itemTemplate string) {
 	if asJSON {
 		buff, err := json.MarshalIndent(valueList, "", " ")
@@ -225,7 +298,7 @@ func outputList(valueList []cinp.Object, header []string, itemTemplate string) {
 		table := tablewriter.NewWriter(os.Stdout)
 		table.SetHeader(header)
 		t := template.New("output")
-		t.Funcs(template.FuncMap{"extractID": extractID, "extractIDList": extractIDList})
+		t.Funcs(template.FuncMap{"extractID": extractID, "extractIDList": extractIDList, "formatIP": formatIP})
 		t, err := t.Parse(itemTemplate)
 		if err != nil {
 			fmt.Println(err)
@@ -255,7 +328,7 @@ func outputDetail(value interface{}, detailTemplate string) {
 		os.Stdout.Write([]byte("\n"))
 	} else {
 		t := template.New("output")
-		t.Funcs(template.FuncMap{"extractID": extractID, "extractIDList": extractIDList})
+		t.Funcs(template.FuncMap{"extractID": extractID, "extractIDList": extractIDList, "formatIP": formatIP})
 		t, err := t.Parse(detailTemplate)
 		if err != nil {
 			fmt.Println(err)
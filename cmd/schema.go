@@ -0,0 +1,453 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Validation of config values against a JSON-Schema style schema.
+
+Only the subset of JSON-Schema that makes sense for Contractor config values is
+supported: type, enum, const, properties, required, additionalProperties,
+patternProperties, items, minItems, maxItems, uniqueItems, minLength, maxLength,
+pattern, minimum, maximum, exclusiveMinimum and exclusiveMaximum.
+
+Top level keys starting with '_' are generated by Contractor (ie: _hostname,
+__contractor_host) and are never considered additional properties.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	cinp "github.com/cinp/go"
+)
+
+type configViolation struct {
+	cinp.BaseObject
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+var schemaExtensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+// siteSchemaName is the name of the schema file for site config values in a schema directory
+const siteSchemaName = "_site"
+
+// findSchemaFile returns the schema file to use, if schema is a directory the file
+// named after the blueprint is looked for in it, or _site if blueprint is "",
+// "" is returned if there is no schema
+func findSchemaFile(schema string, blueprint string) (string, error) {
+	if schema == "" {
+		return "", nil
+	}
+
+	info, err := os.Stat(schema)
+	if err != nil {
+		return "", err
+	}
+
+	if !info.IsDir() {
+		return schema, nil
+	}
+
+	if blueprint == "" {
+		blueprint = siteSchemaName
+	}
+
+	for _, ext := range schemaExtensions {
+		filename := filepath.Join(schema, blueprint+ext)
+		if _, err := os.Stat(filename); err == nil {
+			return filename, nil
+		}
+	}
+
+	return "", nil
+}
+
+func loadSchema(filename string) (map[string]interface{}, error) {
+	format := "json"
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		format = "yaml"
+	case ".toml":
+		format = "toml"
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	schema, err := decodeConfigValues(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("error loading schema '%s': %s", filename, err)
+	}
+
+	return schema, nil
+}
+
+// validateConfig checks values against schema, if partial is true the values are
+// not the complete config (ie: only the values local to a structure) and required
+// is not enforced at the top level
+func validateConfig(schema map[string]interface{}, values map[string]interface{}, partial bool) []*configViolation {
+	result := []*configViolation{}
+
+	top := map[string]interface{}{}
+	for k, v := range values {
+		name := strings.TrimLeft(k, "<>") // prepend/append modifiers
+		if name != k {
+			continue // the result depends on the inherited value, can't validate the type here
+		}
+		top[name] = v
+	}
+
+	if partial {
+		schema = withoutRequired(schema)
+	}
+
+	validateValue(schema, top, "", &result, true)
+
+	for k := range values {
+		name := strings.TrimLeft(k, "<>")
+		if name == k {
+			continue
+		}
+		if !schemaAllowsProperty(schema, name) {
+			result = append(result, &configViolation{Path: name, Message: "not allowed by the schema"})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+
+	return result
+}
+
+func withoutRequired(schema map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, v := range schema {
+		if k != "required" {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func schemaAllowsProperty(schema map[string]interface{}, name string) bool {
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		if _, ok := properties[name]; ok {
+			return true
+		}
+	}
+
+	if patternProperties, ok := schema["patternProperties"].(map[string]interface{}); ok {
+		for pattern := range patternProperties {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(name) {
+				return true
+			}
+		}
+	}
+
+	if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+		return strings.HasPrefix(name, "_")
+	}
+
+	return true
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func schemaTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case float32, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		if f, ok := toFloat(v); ok && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return "unknown"
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func typeMatches(want string, got string) bool {
+	return want == got || (want == "number" && got == "integer")
+}
+
+func valueEqual(a interface{}, b interface{}) bool {
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+	if okA && okB {
+		return fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func describeValue(value interface{}) string {
+	buff, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(buff)
+}
+
+func validateValue(schema map[string]interface{}, value interface{}, path string, result *[]*configViolation, top bool) {
+	fail := func(format string, a ...interface{}) {
+		p := path
+		if p == "" {
+			p = "<root>"
+		}
+		*result = append(*result, &configViolation{Path: p, Message: fmt.Sprintf(format, a...)})
+	}
+
+	valueType := schemaTypeOf(value)
+
+	switch want := schema["type"].(type) {
+	case string:
+		if !typeMatches(want, valueType) {
+			fail("expected type '%s', got '%s'", want, valueType)
+			return
+		}
+	case []interface{}:
+		ok := false
+		names := []string{}
+		for _, item := range want {
+			name, _ := item.(string)
+			names = append(names, name)
+			if typeMatches(name, valueType) {
+				ok = true
+			}
+		}
+		if !ok {
+			fail("expected type one of '%s', got '%s'", strings.Join(names, "', '"), valueType)
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, item := range enum {
+			if valueEqual(item, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value %s is not one of %s", describeValue(value), describeValue(enum))
+		}
+	}
+
+	if constant, ok := schema["const"]; ok {
+		if !valueEqual(constant, value) {
+			fail("value %s is not %s", describeValue(value), describeValue(constant))
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := len([]rune(v))
+		if limit, ok := toFloat(schema["minLength"]); ok && float64(length) < limit {
+			fail("length %d is less than the minimum of %v", length, limit)
+		}
+		if limit, ok := toFloat(schema["maxLength"]); ok && float64(length) > limit {
+			fail("length %d is more than the maximum of %v", length, limit)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				fail("invalid pattern in schema '%s': %s", pattern, err)
+			} else if !re.MatchString(v) {
+				fail("value %s does not match pattern '%s'", describeValue(v), pattern)
+			}
+		}
+
+	case []interface{}:
+		if limit, ok := toFloat(schema["minItems"]); ok && float64(len(v)) < limit {
+			fail("has %d items, less than the minimum of %v", len(v), limit)
+		}
+		if limit, ok := toFloat(schema["maxItems"]); ok && float64(len(v)) > limit {
+			fail("has %d items, more than the maximum of %v", len(v), limit)
+		}
+		if unique, ok := schema["uniqueItems"].(bool); ok && unique {
+			for i := 0; i < len(v); i++ {
+				for j := i + 1; j < len(v); j++ {
+					if valueEqual(v[i], v[j]) {
+						fail("item %d is a duplicate of item %d", j, i)
+					}
+				}
+			}
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateValue(items, item, fmt.Sprintf("%s[%d]", path, i), result, false)
+			}
+		}
+
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		patternProperties, _ := schema["patternProperties"].(map[string]interface{})
+
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, item := range required {
+				name, _ := item.(string)
+				if _, ok := v[name]; !ok {
+					*result = append(*result, &configViolation{Path: joinPath(path, name), Message: "required value is missing"})
+				}
+			}
+		}
+
+		names := []string{}
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			item := v[name]
+			matched := false
+
+			if sub, ok := properties[name].(map[string]interface{}); ok {
+				matched = true
+				validateValue(sub, item, joinPath(path, name), result, false)
+			}
+
+			for pattern, sub := range patternProperties {
+				re, err := regexp.Compile(pattern)
+				if err != nil || !re.MatchString(name) {
+					continue
+				}
+				matched = true
+				if sub, ok := sub.(map[string]interface{}); ok {
+					validateValue(sub, item, joinPath(path, name), result, false)
+				}
+			}
+
+			if matched || (top && strings.HasPrefix(name, "_")) {
+				continue
+			}
+
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					*result = append(*result, &configViolation{Path: joinPath(path, name), Message: "not allowed by the schema"})
+				}
+			case map[string]interface{}:
+				validateValue(additional, item, joinPath(path, name), result, false)
+			}
+		}
+	}
+
+	if number, ok := toFloat(value); ok {
+		if limit, ok := toFloat(schema["minimum"]); ok && number < limit {
+			fail("value %v is less than the minimum of %v", number, limit)
+		}
+		if limit, ok := toFloat(schema["maximum"]); ok && number > limit {
+			fail("value %v is more than the maximum of %v", number, limit)
+		}
+		if limit, ok := toFloat(schema["exclusiveMinimum"]); ok && number <= limit {
+			fail("value %v must be more than %v", number, limit)
+		}
+		if limit, ok := toFloat(schema["exclusiveMaximum"]); ok && number >= limit {
+			fail("value %v must be less than %v", number, limit)
+		}
+	}
+}
+
+// checkConfigSchema validates values with the schema found for blueprint, if there is one,
+// values are expected to be partial, ie: the config values before being compiled
+func checkConfigSchema(blueprint string, values map[string]interface{}) error {
+	filename, err := findSchemaFile(configSchema, blueprint)
+	if err != nil {
+		return err
+	}
+	if filename == "" {
+		return nil
+	}
+
+	schema, err := loadSchema(filename)
+	if err != nil {
+		return err
+	}
+
+	violationList := validateConfig(schema, values, true)
+	if len(violationList) == 0 {
+		return nil
+	}
+
+	lines := []string{}
+	for _, violation := range violationList {
+		lines = append(lines, fmt.Sprintf("  %s: %s", violation.Path, violation.Message))
+	}
+
+	return fmt.Errorf("config values failed validation against '%s':\n%s", filename, strings.Join(lines, "\n"))
+}
@@ -28,8 +28,9 @@ import (
 )
 
 var shellCmd = &cobra.Command{
-	Use:   "shell",
-	Short: "Start Interactive Shell",
+	Use:         "shell",
+	Short:       "Start Interactive Shell",
+	Annotations: map[string]string{"offline": "true"},
 	Run: func(cmd *cobra.Command, args []string) {
 		rootCmd.RemoveCommand(cmd)
 		shell := &cobraprompt.CobraPrompt{
@@ -37,6 +38,7 @@ var shellCmd = &cobra.Command{
 			GoPromptOptions: []prompt.Option{
 				prompt.OptionTitle("contractor"),
 				prompt.OptionPrefix("contractor> "),
+				prompt.OptionLivePrefix(shellPrefix),
 				prompt.OptionMaxSuggestion(10),
 			},
 		}
@@ -44,6 +46,14 @@ var shellCmd = &cobra.Command{
 	},
 }
 
+// shellPrefix follows the active context, which can change with use-context while the shell is running
+func shellPrefix() (string, bool) {
+	if activeContext == "" {
+		return "", false
+	}
+	return "contractor(" + activeContext + ")> ", true
+}
+
 func init() {
 	rootCmd.AddCommand(shellCmd)
 }
@@ -18,11 +18,8 @@ limitations under the License.
 
 import (
 	"errors"
-	"io"
-	"os"
 
 	cinp "github.com/cinp/go"
-	"github.com/pelletier/go-toml/v2"
 	"github.com/spf13/cobra"
 )
 
@@ -209,77 +206,50 @@ var siteConfigCmd = &cobra.Command{
 
 		ctx := cmd.Context()
 
-		if configSetName != "" || configFile != "" || configDeleteName != "" {
+		if configModifying() {
 			r, err := contractorClient.SiteSiteGet(ctx, siteID)
 			if err != nil {
 				return err
 			}
 
-			o := contractorClient.SiteSiteNewWithID(siteID)
-			o.ConfigValues = r.ConfigValues
-
-			if configSetName != "" {
-				(*o.ConfigValues)[configSetName] = configSetValue
-
-			} else if configFile != "" {
-				var reader io.Reader
-				if configFile == "-" {
-					reader = os.Stdin
-				} else {
-					f, err := os.Open(configFile)
-					if err != nil {
-						return err
-					}
-					defer f.Close()
-					reader = f
-				}
-
-				var newValues map[string]interface{}
-				decoder := toml.NewDecoder(reader)
-				err := decoder.Decode(&newValues)
-				if err != nil {
-					return err
-				}
-
-				for k, v := range newValues {
-					(*o.ConfigValues)[k] = v
-				}
-
-			} else if configDeleteName != "" {
-				delete(*o.ConfigValues, configDeleteName)
+			values, err := applyConfigChanges(cmd, *r.ConfigValues)
+			if err != nil {
+				return err
 			}
 
-			err = o.Update(ctx)
-			if err != nil {
+			if err := checkConfigSchema("", values); err != nil {
 				return err
 			}
-			outputKV(*o.ConfigValues)
 
-		} else if configFull {
 			o := contractorClient.SiteSiteNewWithID(siteID)
-			r, err := o.CallGetConfig(ctx)
+			o.ConfigValues = &values
+
+			err = o.Update(ctx)
 			if err != nil {
 				return err
 			}
-			outputKV(r)
+			return outputConfigValues(cmd, *o.ConfigValues)
+		}
 
-		} else {
-			o, err := contractorClient.SiteSiteGet(ctx, siteID)
+		if configFull {
+			o := contractorClient.SiteSiteNewWithID(siteID)
+			r, err := o.CallGetConfig(ctx)
 			if err != nil {
 				return err
 			}
-			outputKV(*o.ConfigValues)
+			return outputConfigValues(cmd, r)
 		}
-		return nil
+
+		o, err := contractorClient.SiteSiteGet(ctx, siteID)
+		if err != nil {
+			return err
+		}
+		return outputConfigValues(cmd, *o.ConfigValues)
 	},
 }
 
 func init() {
-	siteConfigCmd.Flags().BoolVarP(&configFull, "full", "f", false, "Display the Full/Compiled config")
-	siteConfigCmd.Flags().StringVarP(&configSetName, "set-name", "n", "", "Set Config Value Key Name, if set-value is not specified, the value will be set to ''")
-	siteConfigCmd.Flags().StringVarP(&configSetValue, "set-value", "v", "", "Set Config Value, ignored if set-name is not specified")
-	siteConfigCmd.Flags().StringVarP(&configDeleteName, "delete", "d", "", "Delete Config Value Key Name")
-	siteConfigCmd.Flags().StringVarP(&configFile, "file", "i", "", "Load Values from file in TOML format, this will be merged with the existing config, '-' for reading from stdin")
+	addConfigFlags(siteConfigCmd)
 
 	siteCreateCmd.Flags().StringVarP(&detailName, "name", "n", "", "Name of New Site")
 	siteCreateCmd.Flags().StringVarP(&detailDescription, "description", "d", "", "Description of New Site")
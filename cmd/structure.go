@@ -18,13 +18,10 @@ limitations under the License.
 
 import (
 	"errors"
-	"io"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
 
-	"github.com/pelletier/go-toml/v2"
 	contractor "github.com/t3kton/contractor_goclient"
 
 	cinp "github.com/cinp/go"
@@ -38,13 +35,6 @@ func structureArgCheck(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func structureAddressArgCheck(cmd *cobra.Command, args []string) error {
-	if len(args) != 1 {
-		return errors.New("requires a Address Id argument")
-	}
-	return nil
-}
-
 func structureInterfaceArgCheck(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
 		return errors.New("requires a Structure Interface Id argument")
@@ -263,68 +253,45 @@ var structureConfigCmd = &cobra.Command{
 
 		ctx := cmd.Context()
 
-		if configSetName != "" || configFile != "" || configDeleteName != "" {
+		if configModifying() {
 			r, err := contractorClient.BuildingStructureGet(ctx, structureID)
 			if err != nil {
 				return err
 			}
 
-			o := contractorClient.BuildingStructureNewWithID(structureID)
-			o.ConfigValues = r.ConfigValues
-			if configSetName != "" {
-				(*o.ConfigValues)[configSetName] = configSetValue
-
-			} else if configFile != "" {
-				var reader io.Reader
-				if configFile == "-" {
-					reader = os.Stdin
-				} else {
-					f, err := os.Open(configFile)
-					if err != nil {
-						return err
-					}
-					defer f.Close()
-					reader = f
-				}
-
-				var newValues map[string]interface{}
-				decoder := toml.NewDecoder(reader)
-				err := decoder.Decode(&newValues)
-				if err != nil {
-					return err
-				}
-
-				for k, v := range newValues {
-					(*o.ConfigValues)[k] = v
-				}
-
-			} else if configDeleteName != "" {
-				delete(*o.ConfigValues, configDeleteName)
+			values, err := applyConfigChanges(cmd, *r.ConfigValues)
+			if err != nil {
+				return err
 			}
 
-			err = o.Update(ctx)
-			if err != nil {
+			if err := checkConfigSchema(extractID(*r.Blueprint), values); err != nil {
 				return err
 			}
-			outputKV(*o.ConfigValues)
 
-		} else if configFull {
 			o := contractorClient.BuildingStructureNewWithID(structureID)
-			r, err := o.CallGetConfig(ctx)
+			o.ConfigValues = &values
+
+			err = o.Update(ctx)
 			if err != nil {
 				return err
 			}
-			outputKV(r)
+			return outputConfigValues(cmd, *o.ConfigValues)
+		}
 
-		} else {
-			o, err := contractorClient.BuildingStructureGet(ctx, structureID)
+		if configFull {
+			o := contractorClient.BuildingStructureNewWithID(structureID)
+			r, err := o.CallGetConfig(ctx)
 			if err != nil {
 				return err
 			}
-			outputKV(*o.ConfigValues)
+			return outputConfigValues(cmd, r)
 		}
 
-		return nil
+		o, err := contractorClient.BuildingStructureGet(ctx, structureID)
+		if err != nil {
+			return err
+		}
+		return outputConfigValues(cmd, *o.ConfigValues)
 	},
 }
 
@@ -358,7 +325,7 @@ var structureAddressListCmd = &cobra.Command{
 		for v := range vchan {
 			rl = append(rl, v)
 		}
-		outputList(rl, []string{"Id", "Interface", "Address", "Address Block", "Offset", "Is Primary", "Created", "Updated"}, "{{.GetURI | extractID}}	{{.InterfaceName}}	{{.IPAddress}}	{{.AddressBlock | extractID}}	{{.Offset}}	{{.IsPrimary}}	{{.Updated}}	{{.Created}}\n")
+		outputList(rl, addressListHeader, addressListTemplate)
 
 		return nil
 	},
@@ -381,38 +348,7 @@ var structureAddressNextCmd = &cobra.Command{
 			return err
 		}
 
-		a, err := contractorClient.UtilitiesAddressBlockGet(ctx, detailAddressBlock)
-		if err != nil {
-			return err
-		}
-
-		addressURI, err := a.CallNextAddress(ctx, strings.Replace(r.GetURI(), "/api/v1/Building/Structure", "/api/v1/Utilities/Networked", 1), detailInterfaceName, detailIsPrimary)
-		if err != nil {
-			return err
-		}
-
-		o, err := contractorClient.UtilitiesAddressGetURI(ctx, addressURI)
-		if err != nil {
-			return err
-		}
-
-		outputDetail(o, `Id:            {{.GetURI | extractID}}
-AddressBlock:  {{.AddressBlock | extractID}}
-Offset:        {{.Offset}}
-Networked:     {{.Networked | extractID}}
-InterfaceName: {{.InterfaceName}}
-AliasIndex:    {{.AliasIndex}}
-Pointer:       {{.Pointer}}
-IsPrimary:     {{.IsPrimary}}
-Type:          {{.Type}}
-IPAddress:     {{.IPAddress}}
-Subnet:        {{.Subnet}}
-Netmask:       {{.Netmask}}
-Prefix:        {{.Prefix}}
-Created:       {{.Created}}
-Updated:       {{.Updated}}
-`)
-		return nil
+		return nextAddress(ctx, networkedURI(r.GetURI()))
 	},
 }
 
@@ -433,114 +369,35 @@ var structureAddressAddCmd = &cobra.Command{
 			return err
 		}
 
-		a, err := contractorClient.UtilitiesAddressBlockGet(ctx, detailAddressBlock)
-		if err != nil {
-			return err
-		}
-
-		o := contractorClient.UtilitiesAddressNew()
-		addressBlock := a.GetURI()
-		o.AddressBlock = &addressBlock
-		o.Offset = &detailOffset
-		networked := strings.Replace(s.GetURI(), "/api/v1/Building/Structure", "/api/v1/Utilities/Networked", 1)
-		o.Networked = &networked
-		o.InterfaceName = &detailInterfaceName
-		o.IsPrimary = &detailIsPrimary
-
-		err = o.Create(ctx)
-		if err != nil {
-			return err
-		}
-
-		outputDetail(o, `Id:            {{.GetURI | extractID}}
-AddressBlock:  {{.AddressBlock | extractID}}
-Offset:        {{.Offset}}
-Networked:     {{.Networked | extractID}}
-InterfaceName: {{.InterfaceName}}
-AliasIndex:    {{.AliasIndex}}
-Pointer:       {{.Pointer}}
-IsPrimary:     {{.IsPrimary}}
-Type:          {{.Type}}
-IPAddress:     {{.IPAddress}}
-Subnet:        {{.Subnet}}
-Netmask:       {{.Netmask}}
-Prefix:        {{.Prefix}}
-Created:       {{.Created}}
-Updated:       {{.Updated}}
-`)
-		return nil
+		return addAddress(ctx, networkedURI(s.GetURI()))
 	},
 }
 
 var structureAddressUpdateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Update an IP address to structure",
-	Args:  structureAddressArgCheck,
+	Args:  addressArgCheck,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		addressID, err := strconv.Atoi(args[0])
 		if err != nil {
 			return err
 		}
 
-		ctx := cmd.Context()
-
-		o := contractorClient.UtilitiesAddressNewWithID(addressID)
-
-		if detailOffset != 0 {
-			o.Offset = &detailOffset
-		}
-
-		if detailInterfaceName != "" {
-			o.InterfaceName = &detailInterfaceName
-		}
-
-		err = o.Update(ctx)
-		if err != nil {
-			return err
-		}
-
-		outputDetail(o, `Id:            {{.GetURI | extractID}}
-AddressBlock:  {{.AddressBlock | extractID}}
-Offset:        {{.Offset}}
-Networked:     {{.Networked | extractID}}
-InterfaceName: {{.InterfaceName}}
-AliasIndex:    {{.AliasIndex}}
-Pointer:       {{.Pointer}}
-IsPrimary:     {{.IsPrimary}}
-Type:          {{.Type}}
-IPAddress:     {{.IPAddress}}
-Subnet:        {{.Subnet}}
-Netmask:       {{.Netmask}}
-Prefix:        {{.Prefix}}
-Created:       {{.Created}}
-Updated:       {{.Updated}}
-`)
-
-		return nil
+		return updateAddress(cmd.Context(), addressID)
 	},
 }
 
 var structureAddressDeleteCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "Delete IP address from structure",
-	Args:  structureAddressArgCheck,
+	Args:  addressArgCheck,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		addressID, err := strconv.Atoi(args[0])
 		if err != nil {
 			return err
 		}
 
-		ctx := cmd.Context()
-
-		o, err := contractorClient.UtilitiesAddressGet(ctx, addressID)
-		if err != nil {
-			return err
-		}
-		if err := o.Delete(ctx); err != nil {
-			return err
-		}
-
-		return nil
+		return deleteAddress(cmd.Context(), addressID)
 	},
 }
 
@@ -1095,11 +952,7 @@ var structureAggInterfaceDeleteCmd = &cobra.Command{
 }
 
 func init() {
-	structureConfigCmd.Flags().BoolVarP(&configFull, "full", "f", false, "Display the Full/Compiled config")
-	structureConfigCmd.Flags().StringVarP(&configSetName, "set-name", "n", "", "Set Config Value Key Name, if set-value is not specified, the value will be set to ''")
-	structureConfigCmd.Flags().StringVarP(&configSetValue, "set-value", "v", "", "Set Config Value, ignored if set-name is not specified") // TODO: make a numberic version
-	structureConfigCmd.Flags().StringVarP(&configDeleteName, "delete", "d", "", "Delete Config Value Key Name")
-	structureConfigCmd.Flags().StringVarP(&configFile, "file", "i", "", "Load Values from file in TOML format, this will be merged with the existing config, '-' for reading from stdin")
+	addConfigFlags(structureConfigCmd)
 
 	structureCreateCmd.Flags().StringVarP(&detailHostname, "hostname", "o", "", "Hostname of New Structure")
 	structureCreateCmd.Flags().StringVarP(&detailSite, "site", "s", "", "Site of New Structure")
@@ -1111,17 +964,7 @@ func init() {
 	structureUpdateCmd.Flags().StringVarP(&detailBlueprint, "blueprint", "b", "", "Update the Blueprint of Structure with value")
 	structureUpdateCmd.Flags().StringVarP(&detailFoundation, "foundation", "f", "", "Update the Foundation of Structure with value")
 
-	structureAddressNextCmd.Flags().IntVarP(&detailAddressBlock, "addressblock", "a", 0, "Address Block to get an IP From")
-	structureAddressNextCmd.Flags().StringVarP(&detailInterfaceName, "interfacename", "n", "", "Name of the Interface to assigne the IP To")
-	structureAddressNextCmd.Flags().BoolVarP(&detailIsPrimary, "primary", "p", false, "If this is the primary IP Address")
-
-	structureAddressAddCmd.Flags().IntVarP(&detailAddressBlock, "addressblock", "a", 0, "Address Block to get an IP From")
-	structureAddressAddCmd.Flags().StringVarP(&detailInterfaceName, "interfacename", "n", "", "Name of the Interface to assigne the IP To")
-	structureAddressAddCmd.Flags().IntVarP(&detailOffset, "offset", "o", 0, "Offset inside the Address Block to use")
-	structureAddressAddCmd.Flags().BoolVarP(&detailIsPrimary, "primary", "p", false, "If this is the primary IP Address")
-
-	structureAddressUpdateCmd.Flags().StringVarP(&detailInterfaceName, "interfacename", "n", "", "Name of the Interface to assigne the IP To")
-	structureAddressUpdateCmd.Flags().IntVarP(&detailOffset, "offset", "o", 0, "Offset inside the Address Block to use")
+	addressFlags(structureAddressNextCmd, structureAddressAddCmd, structureAddressUpdateCmd)
 
 	structureInterfaceCreateCmd.Flags().StringVarP(&detailName, "name", "n", "", "Name of the new Interface")
 	structureInterfaceCreateCmd.Flags().IntVarP(&detailNetwork, "network", "t", 0, "Network id to attach the new Interface to")
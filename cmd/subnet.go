@@ -0,0 +1,139 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+
+	contractor "github.com/t3kton/contractor_goclient"
+)
+
+// blockNetwork returns the network of the Address Block
+func blockNetwork(block *contractor.UtilitiesAddressBlock) (*net.IPNet, error) {
+	if block.Subnet == nil || block.Prefix == nil {
+		return nil, fmt.Errorf("address block is missing its subnet or prefix")
+	}
+	_, network, err := net.ParseCIDR(fmt.Sprintf("%s/%d", *block.Subnet, *block.Prefix))
+	if err != nil {
+		return nil, err
+	}
+	return network, nil
+}
+
+// networkSize returns the number of addresses in network, including the network and broadcast addresses
+func networkSize(network *net.IPNet) *big.Int {
+	ones, bits := network.Mask.Size()
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+}
+
+// networkIP returns the ip at offset in network
+func networkIP(network *net.IPNet, offset *big.Int) net.IP {
+	base := network.IP.To4()
+	if base == nil {
+		base = network.IP.To16()
+	}
+	value := new(big.Int).Add(new(big.Int).SetBytes(base), offset)
+	buff := value.Bytes()
+	result := make(net.IP, len(base))
+	copy(result[len(result)-len(buff):], buff)
+	return result
+}
+
+// offsetIP returns the ip at offset in network
+func offsetIP(network *net.IPNet, offset int) net.IP {
+	return networkIP(network, big.NewInt(int64(offset)))
+}
+
+// ipOffset returns the offset of ip in network, false if ip is not in network
+func ipOffset(network *net.IPNet, ip net.IP) (*big.Int, bool) {
+	if !network.Contains(ip) {
+		return nil, false
+	}
+	base := network.IP.To4()
+	if base == nil {
+		base = network.IP.To16()
+		ip = ip.To16()
+	} else {
+		ip = ip.To4()
+	}
+	return new(big.Int).Sub(new(big.Int).SetBytes(ip), new(big.Int).SetBytes(base)), true
+}
+
+// usableOffsets returns the first and last offset that can be assigned in network, for IPv4
+// the network and broadcast addresses are skipped, except for /31 and /32
+func usableOffsets(network *net.IPNet) (*big.Int, *big.Int) {
+	ones, bits := network.Mask.Size()
+	last := new(big.Int).Sub(networkSize(network), big.NewInt(1))
+	if bits == 32 && ones < 31 {
+		return big.NewInt(1), last.Sub(last, big.NewInt(1))
+	}
+	return big.NewInt(0), last
+}
+
+// parseCIDR returns the subnet and prefix of value, which must be aligned
+func parseCIDR(value string) (string, int, error) {
+	ip, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return "", 0, err
+	}
+	prefix, _ := network.Mask.Size()
+	if !ip.Equal(network.IP) {
+		return "", 0, fmt.Errorf("'%s' is not aligned to the prefix %d, did you mean '%s'", value, prefix, network.String())
+	}
+	return network.IP.String(), prefix, nil
+}
+
+// gatewayOffset returns the offset of the gateway ip in network, the gateway must be a usable address
+func gatewayOffset(network *net.IPNet, gateway string) (int, error) {
+	ip := net.ParseIP(gateway)
+	if ip == nil {
+		return 0, fmt.Errorf("invalid gateway ip '%s'", gateway)
+	}
+	offset, ok := ipOffset(network, ip)
+	if !ok {
+		return 0, fmt.Errorf("gateway ip '%s' is not in '%s'", gateway, network.String())
+	}
+	first, last := usableOffsets(network)
+	if offset.Cmp(first) < 0 || offset.Cmp(last) > 0 {
+		return 0, fmt.Errorf("gateway ip '%s' is not a usable address of '%s'", gateway, network.String())
+	}
+	if !offset.IsInt64() || offset.Int64() > int64(^uint32(0)>>1) {
+		return 0, fmt.Errorf("gateway ip '%s' offset is too large", gateway)
+	}
+	return int(offset.Int64()), nil
+}
+
+// checkBlockOverlap returns an error if network overlaps any existing Address Block of the site
+func checkBlockOverlap(ctx context.Context, site string, network *net.IPNet) error {
+	vchan, err := contractorClient.UtilitiesAddressBlockList(ctx, "site", map[string]interface{}{"site": site})
+	if err != nil {
+		return err
+	}
+	for v := range vchan {
+		other, err := blockNetwork(v)
+		if err != nil {
+			continue
+		}
+		if other.Contains(network.IP) || network.Contains(other.IP) {
+			return fmt.Errorf("'%s' overlaps AddressBlock %s(%s) '%s'", network.String(), extractID(v.GetURI()), stringValue(v.Name), other.String())
+		}
+	}
+	return nil
+}
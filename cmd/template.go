@@ -0,0 +1,890 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Local rendering of the Jinja style templates Contractor uses for PXE boot
+scripts and templates.
+
+Supported is the subset used in practice:
+  {{ expr }}, {# comment #}, {% if %}/{% elif %}/{% else %}/{% endif %},
+  {% for x in expr %}/{% for k, v in expr %}/{% endfor %}
+Expressions are variables with .attr and [index] access, strings, numbers,
+true/false/none, not, and, or, ==, !=, <, <=, >, >=, in, 'is defined',
+'is not defined' and the filters default, lower, upper, trim, length and join.
+
+Whitespace control is supported with {{- -}}, {%- -%} and {#- -#}, lists and dicts
+are output the way Python prints them.
+
+Undefined variables are errors, unless guarded by 'is defined' or the default filter.
+*/
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type templateError struct {
+	Line    int
+	Message string
+}
+
+func (e *templateError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+type templateUndefined struct {
+	name string
+}
+
+type templateToken struct {
+	kind string // "text", "expr", "tag"
+	body string
+	line int
+}
+
+type templateNode interface{}
+
+type templateText struct {
+	text string
+}
+
+type templateExpr struct {
+	expr string
+	line int
+}
+
+type templateBranch struct {
+	cond  string // "" for else
+	line  int
+	nodes []templateNode
+}
+
+type templateIf struct {
+	branchList []*templateBranch
+}
+
+type templateFor struct {
+	names []string
+	expr  string
+	line  int
+	nodes []templateNode
+}
+
+// tokenizeTemplate splits source into text, expr and tag tokens, comments are dropped, a '-' just inside
+// a delimiter, ie: {%- or -%}, trims the whitespace of the text before or after it
+func tokenizeTemplate(source string) ([]templateToken, error) {
+	result := []templateToken{}
+	line := 1
+	trimNext := false
+	addText := func(text string) {
+		if trimNext {
+			text = strings.TrimLeft(text, " \t\r\n")
+		}
+		if text != "" {
+			result = append(result, templateToken{"text", text, line})
+		}
+	}
+	for len(source) > 0 {
+		start := strings.Index(source, "{")
+		for start != -1 && (start+1 >= len(source) || !strings.ContainsRune("{%#", rune(source[start+1]))) {
+			next := strings.Index(source[start+1:], "{")
+			if next == -1 {
+				start = -1
+			} else {
+				start += next + 1
+			}
+		}
+		if start == -1 {
+			addText(source)
+			break
+		}
+
+		if start > 0 {
+			addText(source[:start])
+			line += strings.Count(source[:start], "\n")
+		}
+
+		closer := map[byte]string{'{': "}}", '%': "%}", '#': "#}"}[source[start+1]]
+		end := strings.Index(source[start+2:], closer)
+		if end == -1 {
+			return nil, &templateError{line, fmt.Sprintf("missing closing '%s'", closer)}
+		}
+		body := source[start+2 : start+2+end]
+		if strings.HasPrefix(body, "-") && len(result) > 0 && result[len(result)-1].kind == "text" {
+			result[len(result)-1].body = strings.TrimRight(result[len(result)-1].body, " \t\r\n")
+		}
+		trimNext = strings.HasSuffix(body, "-")
+		if len(body) > 0 && (body[0] == '-' || body[0] == '+') {
+			body = body[1:]
+		}
+		if len(body) > 0 && (body[len(body)-1] == '-' || body[len(body)-1] == '+') {
+			body = body[:len(body)-1]
+		}
+		switch source[start+1] {
+		case '{':
+			result = append(result, templateToken{"expr", strings.TrimSpace(body), line})
+		case '%':
+			result = append(result, templateToken{"tag", strings.TrimSpace(body), line})
+		}
+		line += strings.Count(source[start:start+4+end], "\n")
+		source = source[start+4+end:]
+	}
+
+	return result, nil
+}
+
+func parseTemplate(source string) ([]templateNode, error) {
+	tokenList, err := tokenizeTemplate(source)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := 0
+	var parseBlock func(enders []string) ([]templateNode, string, int, error)
+	parseBlock = func(enders []string) ([]templateNode, string, int, error) {
+		result := []templateNode{}
+		for pos < len(tokenList) {
+			token := tokenList[pos]
+			pos++
+			switch token.kind {
+			case "text":
+				result = append(result, &templateText{token.body})
+			case "expr":
+				result = append(result, &templateExpr{token.body, token.line})
+			case "tag":
+				word := strings.Fields(token.body + " ")
+				name := ""
+				if len(word) > 0 {
+					name = word[0]
+				}
+				for _, ender := range enders {
+					if name == ender {
+						return result, token.body, token.line, nil
+					}
+				}
+				switch name {
+				case "if":
+					node := &templateIf{}
+					cond := strings.TrimSpace(strings.TrimPrefix(token.body, "if"))
+					line := token.line
+					for {
+						nodes, ender, enderLine, err := parseBlock([]string{"elif", "else", "endif"})
+						if err != nil {
+							return nil, "", 0, err
+						}
+						if ender == "" {
+							return nil, "", 0, &templateError{token.line, "'if' is missing its 'endif'"}
+						}
+						node.branchList = append(node.branchList, &templateBranch{cond, line, nodes})
+						if ender == "endif" {
+							break
+						}
+						if ender == "else" {
+							cond = ""
+						} else {
+							cond = strings.TrimSpace(strings.TrimPrefix(ender, "elif"))
+						}
+						line = enderLine
+					}
+					result = append(result, node)
+
+				case "for":
+					parts := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(token.body, "for")), " in ", 2)
+					if len(parts) != 2 {
+						return nil, "", 0, &templateError{token.line, "expected 'for <name> in <expression>'"}
+					}
+					names := []string{}
+					for _, name := range strings.Split(parts[0], ",") {
+						names = append(names, strings.TrimSpace(name))
+					}
+					nodes, ender, _, err := parseBlock([]string{"endfor"})
+					if err != nil {
+						return nil, "", 0, err
+					}
+					if ender == "" {
+						return nil, "", 0, &templateError{token.line, "'for' is missing its 'endfor'"}
+					}
+					result = append(result, &templateFor{names, parts[1], token.line, nodes})
+
+				default:
+					return nil, "", 0, &templateError{token.line, fmt.Sprintf("unsupported or unexpected tag '%s'", name)}
+				}
+			}
+		}
+		return result, "", 0, nil
+	}
+
+	result, _, _, err := parseBlock(nil)
+	return result, err
+}
+
+// renderTemplate renders source with values, undefined values are errors
+func renderTemplate(source string, values map[string]interface{}) (string, error) {
+	nodeList, err := parseTemplate(source)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	if err := renderTemplateNodes(&builder, nodeList, values); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+func renderTemplateNodes(builder *strings.Builder, nodeList []templateNode, values map[string]interface{}) error {
+	for _, node := range nodeList {
+		switch n := node.(type) {
+		case *templateText:
+			builder.WriteString(n.text)
+
+		case *templateExpr:
+			value, err := evalTemplateExpr(n.expr, values, n.line)
+			if err != nil {
+				return err
+			}
+			if undefined, ok := value.(*templateUndefined); ok {
+				return &templateError{n.line, fmt.Sprintf("'%s' is undefined", undefined.name)}
+			}
+			builder.WriteString(templateString(value))
+
+		case *templateIf:
+			for _, branch := range n.branchList {
+				if branch.cond != "" {
+					value, err := evalTemplateExpr(branch.cond, values, branch.line)
+					if err != nil {
+						return err
+					}
+					if undefined, ok := value.(*templateUndefined); ok {
+						return &templateError{branch.line, fmt.Sprintf("'%s' is undefined", undefined.name)}
+					}
+					if !templateTruth(value) {
+						continue
+					}
+				}
+				if err := renderTemplateNodes(builder, branch.nodes, values); err != nil {
+					return err
+				}
+				break
+			}
+
+		case *templateFor:
+			value, err := evalTemplateExpr(n.expr, values, n.line)
+			if err != nil {
+				return err
+			}
+			if undefined, ok := value.(*templateUndefined); ok {
+				return &templateError{n.line, fmt.Sprintf("'%s' is undefined", undefined.name)}
+			}
+
+			itemList := [][]interface{}{}
+			switch v := value.(type) {
+			case []interface{}:
+				for _, item := range v {
+					if len(n.names) == 1 {
+						itemList = append(itemList, []interface{}{item})
+					} else if pair, ok := item.([]interface{}); ok && len(pair) == len(n.names) {
+						itemList = append(itemList, pair)
+					} else {
+						return &templateError{n.line, fmt.Sprintf("can not unpack %s into %d names", describeValue(item), len(n.names))}
+					}
+				}
+			case map[string]interface{}:
+				keyList := []string{}
+				for k := range v {
+					keyList = append(keyList, k)
+				}
+				sort.Strings(keyList)
+				for _, k := range keyList {
+					itemList = append(itemList, []interface{}{k, v[k]})
+				}
+			default:
+				return &templateError{n.line, fmt.Sprintf("can not iterate over %s", describeValue(value))}
+			}
+
+			for _, item := range itemList {
+				scope := map[string]interface{}{}
+				for k, v := range values {
+					scope[k] = v
+				}
+				for i, name := range n.names {
+					if i < len(item) {
+						scope[name] = item[i]
+					}
+				}
+				if err := renderTemplateNodes(builder, n.nodes, scope); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func templateString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "None"
+	case string:
+		return v
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	if f, ok := toFloat(value); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return templateRepr(value)
+}
+
+// templateRepr returns value the way Python prints it inside a list or dict, ie: ['p', 'q']
+func templateRepr(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		escaped := strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\t", "\\t").Replace(v)
+		if strings.Contains(v, "'") && !strings.Contains(v, "\"") {
+			return "\"" + escaped + "\""
+		}
+		return "'" + strings.ReplaceAll(escaped, "'", "\\'") + "'"
+	case []interface{}:
+		parts := []string{}
+		for _, item := range v {
+			parts = append(parts, templateRepr(item))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case map[string]interface{}:
+		keyList := []string{}
+		for k := range v {
+			keyList = append(keyList, k)
+		}
+		sort.Strings(keyList)
+		parts := []string{}
+		for _, k := range keyList {
+			parts = append(parts, templateRepr(k)+": "+templateRepr(v[k]))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case nil, bool, float64:
+		return templateString(v)
+	}
+	if _, ok := toFloat(value); ok {
+		return templateString(value)
+	}
+	return describeValue(value)
+}
+
+func templateTruth(value interface{}) bool {
+	switch v := value.(type) {
+	case nil, *templateUndefined:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	if f, ok := toFloat(value); ok {
+		return f != 0
+	}
+	return true
+}
+
+// template expressions
+
+type templateExprParser struct {
+	tokens []string
+	pos    int
+	values map[string]interface{}
+	line   int
+}
+
+func tokenizeTemplateExpr(expr string, line int) ([]string, error) {
+	result := []string{}
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '\'' || c == '"':
+			j := i + 1
+			for j < len(expr) && expr[j] != c {
+				if expr[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(expr) {
+				return nil, &templateError{line, "unterminated string"}
+			}
+			result = append(result, expr[i:j+1])
+			i = j + 1
+		case strings.ContainsRune("=!<>", rune(c)) && i+1 < len(expr) && expr[i+1] == '=':
+			result = append(result, expr[i:i+2])
+			i += 2
+		case strings.ContainsRune(".[](),|<>~+-", rune(c)):
+			result = append(result, string(c))
+			i++
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9'):
+			j := i
+			for j < len(expr) && (expr[j] == '_' || (expr[j] >= 'a' && expr[j] <= 'z') || (expr[j] >= 'A' && expr[j] <= 'Z') || (expr[j] >= '0' && expr[j] <= '9')) {
+				j++
+			}
+			result = append(result, expr[i:j])
+			i = j
+		default:
+			return nil, &templateError{line, fmt.Sprintf("unexpected character '%c'", c)}
+		}
+	}
+	return result, nil
+}
+
+func evalTemplateExpr(expr string, values map[string]interface{}, line int) (interface{}, error) {
+	tokens, err := tokenizeTemplateExpr(expr, line)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, &templateError{line, "empty expression"}
+	}
+
+	p := &templateExprParser{tokens: tokens, values: values, line: line}
+	value, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.fail("unexpected '%s'", p.tokens[p.pos])
+	}
+
+	return value, nil
+}
+
+func (p *templateExprParser) fail(format string, a ...interface{}) error {
+	return &templateError{p.line, fmt.Sprintf(format, a...)}
+}
+
+func (p *templateExprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *templateExprParser) expect(token string) error {
+	if p.peek() != token {
+		if p.peek() == "" {
+			return p.fail("expected '%s', got end of expression", token)
+		}
+		return p.fail("expected '%s', got '%s'", token, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+// defined makes sure value is not undefined, for when the value is used
+func (p *templateExprParser) defined(value interface{}) error {
+	if undefined, ok := value.(*templateUndefined); ok {
+		return p.fail("'%s' is undefined", undefined.name)
+	}
+	return nil
+}
+
+func (p *templateExprParser) parseOr() (interface{}, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "or" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if !templateTruth(left) {
+			left = right
+		}
+	}
+	return left, nil
+}
+
+func (p *templateExprParser) parseAnd() (interface{}, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "and" {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if templateTruth(left) {
+			left = right
+		}
+	}
+	return left, nil
+}
+
+func (p *templateExprParser) parseNot() (interface{}, error) {
+	if p.peek() == "not" {
+		p.pos++
+		value, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.defined(value); err != nil {
+			return nil, err
+		}
+		return !templateTruth(value), nil
+	}
+	return p.parseCompare()
+}
+
+func (p *templateExprParser) parseCompare() (interface{}, error) {
+	left, err := p.parseFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek() == "is" {
+		p.pos++
+		negate := false
+		if p.peek() == "not" {
+			negate = true
+			p.pos++
+		}
+		test := p.peek()
+		p.pos++
+		var result bool
+		switch test {
+		case "defined":
+			_, undefined := left.(*templateUndefined)
+			result = !undefined
+		case "none":
+			result = left == nil
+		default:
+			return nil, p.fail("unsupported test '%s'", test)
+		}
+		return result != negate, nil
+	}
+
+	op := p.peek()
+	if op != "==" && op != "!=" && op != "<" && op != "<=" && op != ">" && op != ">=" && op != "in" {
+		return left, nil
+	}
+	p.pos++
+	right, err := p.parseFilter()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.defined(left); err != nil {
+		return nil, err
+	}
+	if err := p.defined(right); err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "==":
+		return valueEqual(left, right), nil
+	case "!=":
+		return !valueEqual(left, right), nil
+	case "in":
+		switch r := right.(type) {
+		case []interface{}:
+			for _, item := range r {
+				if valueEqual(item, left) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]interface{}:
+			_, ok := r[templateString(left)]
+			return ok, nil
+		case string:
+			return strings.Contains(r, templateString(left)), nil
+		}
+		return nil, p.fail("can not use 'in' with %s", describeValue(right))
+	}
+
+	lf, lok := toFloat(left)
+	rf, rok := toFloat(right)
+	if lok && rok {
+		switch op {
+		case "<":
+			return lf < rf, nil
+		case "<=":
+			return lf <= rf, nil
+		case ">":
+			return lf > rf, nil
+		}
+		return lf >= rf, nil
+	}
+	ls, lok := left.(string)
+	rs, rok := right.(string)
+	if lok && rok {
+		switch op {
+		case "<":
+			return ls < rs, nil
+		case "<=":
+			return ls <= rs, nil
+		case ">":
+			return ls > rs, nil
+		}
+		return ls >= rs, nil
+	}
+	return nil, p.fail("can not compare %s and %s", describeValue(left), describeValue(right))
+}
+
+func (p *templateExprParser) parseFilter() (interface{}, error) {
+	value, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "|" {
+		p.pos++
+		name := p.peek()
+		p.pos++
+		argList := []interface{}{}
+		if p.peek() == "(" {
+			p.pos++
+			for p.peek() != ")" {
+				arg, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				argList = append(argList, arg)
+				if p.peek() == "," {
+					p.pos++
+				} else if p.peek() != ")" {
+					return nil, p.fail("expected ',' or ')' in filter arguments")
+				}
+			}
+			p.pos++
+		}
+
+		if name == "default" || name == "d" {
+			if _, ok := value.(*templateUndefined); ok {
+				if len(argList) > 0 {
+					value = argList[0]
+				} else {
+					value = ""
+				}
+			}
+			continue
+		}
+
+		if err := p.defined(value); err != nil {
+			return nil, err
+		}
+
+		switch name {
+		case "lower":
+			value = strings.ToLower(templateString(value))
+		case "upper":
+			value = strings.ToUpper(templateString(value))
+		case "trim":
+			value = strings.TrimSpace(templateString(value))
+		case "string":
+			value = templateString(value)
+		case "length", "count":
+			switch v := value.(type) {
+			case string:
+				value = float64(len([]rune(v)))
+			case []interface{}:
+				value = float64(len(v))
+			case map[string]interface{}:
+				value = float64(len(v))
+			default:
+				return nil, p.fail("%s has no length", describeValue(value))
+			}
+		case "join":
+			separator := ""
+			if len(argList) > 0 {
+				separator = templateString(argList[0])
+			}
+			list, ok := value.([]interface{})
+			if !ok {
+				return nil, p.fail("join requires a list, got %s", describeValue(value))
+			}
+			parts := []string{}
+			for _, item := range list {
+				parts = append(parts, templateString(item))
+			}
+			value = strings.Join(parts, separator)
+		default:
+			return nil, p.fail("unsupported filter '%s'", name)
+		}
+	}
+
+	return value, nil
+}
+
+func (p *templateExprParser) parsePostfix() (interface{}, error) {
+	value, name, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var key interface{}
+		switch p.peek() {
+		case ".":
+			p.pos++
+			key = p.peek()
+			p.pos++
+			name += "." + templateString(key)
+		case "[":
+			p.pos++
+			key, err = p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			name += "[" + describeValue(key) + "]"
+		default:
+			return value, nil
+		}
+
+		if _, ok := value.(*templateUndefined); ok {
+			continue // stays undefined, name is extended for the error
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			item, ok := v[templateString(key)]
+			if !ok {
+				value = &templateUndefined{name}
+			} else {
+				value = item
+			}
+		case []interface{}:
+			index, ok := toFloat(key)
+			if !ok {
+				if s, isString := key.(string); isString {
+					index, err = strconv.ParseFloat(s, 64)
+					ok = err == nil
+				}
+			}
+			if !ok || int(index) < 0 || int(index) >= len(v) {
+				value = &templateUndefined{name}
+			} else {
+				value = v[int(index)]
+			}
+		default:
+			value = &templateUndefined{name}
+		}
+	}
+}
+
+func (p *templateExprParser) parsePrimary() (interface{}, string, error) {
+	token := p.peek()
+	if token == "" {
+		return nil, "", p.fail("unexpected end of expression")
+	}
+	p.pos++
+
+	switch {
+	case token == "(":
+		value, err := p.parseOr()
+		if err != nil {
+			return nil, "", err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, "", err
+		}
+		return value, "(...)", nil
+
+	case token == "[":
+		list := []interface{}{}
+		for p.peek() != "]" {
+			item, err := p.parseOr()
+			if err != nil {
+				return nil, "", err
+			}
+			list = append(list, item)
+			if p.peek() == "," {
+				p.pos++
+			} else if p.peek() != "]" {
+				return nil, "", p.fail("expected ',' or ']' in list")
+			}
+		}
+		p.pos++
+		return list, "[...]", nil
+
+	case token[0] == '\'' || token[0] == '"':
+		value := token[1 : len(token)-1]
+		value = strings.ReplaceAll(value, "\\"+string(token[0]), string(token[0]))
+		return value, token, nil
+
+	case token == "-":
+		value, name, err := p.parsePrimary()
+		if err != nil {
+			return nil, "", err
+		}
+		f, ok := toFloat(value)
+		if !ok {
+			return nil, "", p.fail("can not negate %s", describeValue(value))
+		}
+		return -f, "-" + name, nil
+
+	case token[0] >= '0' && token[0] <= '9':
+		if p.peek() == "." && p.pos+1 < len(p.tokens) {
+			token += "." + p.tokens[p.pos+1]
+			p.pos += 2
+		}
+		f, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, "", p.fail("invalid number '%s'", token)
+		}
+		return f, token, nil
+	}
+
+	switch token {
+	case "true", "True":
+		return true, token, nil
+	case "false", "False":
+		return false, token, nil
+	case "none", "None":
+		return nil, token, nil
+	}
+
+	if !(token[0] == '_' || (token[0] >= 'a' && token[0] <= 'z') || (token[0] >= 'A' && token[0] <= 'Z')) {
+		return nil, "", p.fail("unexpected '%s'", token)
+	}
+
+	value, ok := p.values[token]
+	if !ok {
+		return &templateUndefined{token}, token, nil
+	}
+	return value, token, nil
+}
@@ -0,0 +1,134 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	values := map[string]interface{}{
+		"a":     true,
+		"empty": "",
+		"name":  "  Host1 ",
+		"n":     float64(3),
+		"f":     1.5,
+		"l":     []interface{}{"p", "q"},
+		"d":     map[string]interface{}{"b": "x", "a": float64(1), "c": []interface{}{true, nil}},
+		"q":     "it's",
+		"nested": []interface{}{
+			map[string]interface{}{"name": "eth0", "ips": []interface{}{"10.0.0.1", "10.0.0.2"}},
+			map[string]interface{}{"name": "eth1", "ips": []interface{}{}},
+		},
+	}
+
+	cases := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"text", "plain text", "plain text"},
+		{"variable", "{{ n }}", "3"},
+		{"float", "{{ f }}", "1.5"},
+		{"negative number", "{{ -1 }}", "-1"},
+		{"negative variable", "{{ - n }}", "-3"},
+		{"bool and none", "{{ a }} {{ none }}", "True None"},
+		{"comment", "a{# note #}b", "ab"},
+		{"list", "{{ l }}", "['p', 'q']"},
+		{"dict", "{{ d }}", "{'a': 1, 'b': 'x', 'c': [True, None]}"},
+		{"list with quote", "{{ [q] }}", `["it's"]`},
+		{"attr and index", "{{ d.b }}{{ l[1] }}{{ d['a'] }}", "xq1"},
+
+		{"trim block", "{%- if a %}\n  yes\n{%- endif %}", "\n  yes"},
+		{"trim both", "a  {{- l[0] -}}  b", "apb"},
+		{"trim tag right", "{% if a -%}\n  yes\n{% endif %}", "yes\n"},
+		{"trim comment", "a\n{#- note -#}\nb", "ab"},
+		{"plus marker", "a {%+ if a +%} b{% endif %}", "a  b"},
+		{"no trim", "a {% if a %} b {% endif %} c", "a  b  c"},
+
+		{"filter lower", "{{ name | lower }}", "  host1 "},
+		{"filter upper trim", "{{ name | trim | upper }}", "HOST1"},
+		{"filter length", "{{ l | length }} {{ q | length }} {{ d | count }}", "2 4 3"},
+		{"filter join", "{{ l | join(', ') }}", "p, q"},
+		{"filter default", "{{ missing | default('none') }} {{ n | default(5) }}", "none 3"},
+		{"filter string", "{{ n | string | length }}", "1"},
+
+		{"if elif else", "{% if n > 5 %}big{% elif n > 1 %}mid{% else %}small{% endif %}", "mid"},
+		{"if not", "{% if not empty %}empty{% endif %}", "empty"},
+		{"if defined", "{% if missing is defined %}yes{% else %}no{% endif %}", "no"},
+		{"if in", "{% if 'q' in l and 'a' in d %}yes{% endif %}", "yes"},
+		{"if or", "{% if empty or n == 3 %}yes{% endif %}", "yes"},
+		{"for", "{% for x in l %}[{{ x }}]{% endfor %}", "[p][q]"},
+		{"for dict", "{% for k, v in d %}{{ k }}={{ v }};{% endfor %}", "a=1;b=x;c=[True, None];"},
+		{"for if", "{% for x in l %}{% if x == 'p' %}P{% else %}{{ x }}{% endif %}{% endfor %}", "Pq"},
+		{"for nested", "{% for i in nested %}{{ i.name }}:{% for ip in i.ips %} {{ ip }}{% endfor %};{% endfor %}", "eth0: 10.0.0.1 10.0.0.2;eth1:;"},
+		{"if for", "{% if nested %}{% for i in nested %}{% if i.ips %}{{ i.name }}{% endif %}{% endfor %}{% endif %}", "eth0"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := renderTemplate(c.source, values)
+			if err != nil {
+				t.Fatalf("renderTemplate(%q) returned error: %s", c.source, err)
+			}
+			if got != c.want {
+				t.Errorf("renderTemplate(%q) = %q, want %q", c.source, got, c.want)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	values := map[string]interface{}{
+		"l": []interface{}{"p"},
+		"d": map[string]interface{}{"a": float64(1)},
+	}
+
+	cases := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"undefined", "{{ missing }}", "line 1: 'missing' is undefined"},
+		{"undefined attr", "{{ d.b }}", "line 1: 'd.b' is undefined"},
+		{"undefined index", "{{ l[3] }}", "line 1: 'l[3]' is undefined"},
+		{"undefined line", "a\nb\n{{ missing }}", "line 3: 'missing' is undefined"},
+		{"undefined if", "{% if missing %}x{% endif %}", "line 1: 'missing' is undefined"},
+		{"undefined for", "{% for x in missing %}{% endfor %}", "line 1: 'missing' is undefined"},
+		{"undefined in for", "{% for x in l %}\n{{ x.name }}{% endfor %}", "line 2: 'x.name' is undefined"},
+		{"undefined filter", "{{ missing | upper }}", "line 1: 'missing' is undefined"},
+		{"missing endif", "{% if l %}x", "line 1: 'if' is missing its 'endif'"},
+		{"missing endfor", "\n{% for x in l %}x", "line 2: 'for' is missing its 'endfor'"},
+		{"missing close", "{{ l", "line 1: missing closing '}}'"},
+		{"unknown tag", "{% set x = 1 %}", "line 1: unsupported or unexpected tag 'set'"},
+		{"unknown filter", "{{ l | sort }}", "line 1: unsupported filter 'sort'"},
+		{"bad for", "{% for x %}{% endfor %}", "line 1: expected 'for <name> in <expression>'"},
+		{"not iterable", "{% for x in d.a %}{% endfor %}", "line 1: can not iterate over 1"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := renderTemplate(c.source, values)
+			if err == nil {
+				t.Fatalf("renderTemplate(%q) did not return an error", c.source)
+			}
+			if err.Error() != c.want {
+				t.Errorf("renderTemplate(%q) error = %q, want %q", c.source, err.Error(), c.want)
+			}
+		})
+	}
+}
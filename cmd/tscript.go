@@ -0,0 +1,574 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+A syntax checker for Contractor's tscript, this only checks that the script
+will parse, it does not know which modules/functions are loaded on the server.
+
+line        = statement? comment? newline
+statement   = jump_point / goto / ifelse / whiledo / block / assignment / expression
+jump_point  = ":" label
+goto        = "goto" label
+ifelse      = "if" expression "then" lines ( "elif" expression "then" lines )* ( "else" lines )? "end"
+whiledo     = "while" expression "do" lines "end"
+block       = "begin(" paramaters ")" lines "end"
+assignment  = ( variable / array_item ) "=" expression
+expression  = infix / "not" expression / function / exists / array_item / variable / constant / array / map
+constant    = number / time / text / "True" / "False" / "None"
+function    = ( module "." )? name "(" paramaters ")"
+paramaters  = ( name "=" expression ( "," name "=" expression )* )?
+*/
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tscriptTokenType int
+
+const (
+	tsEOF tscriptTokenType = iota
+	tsNewline
+	tsIdent
+	tsNumber
+	tsString
+	tsPunct
+)
+
+type tscriptToken struct {
+	Type   tscriptTokenType
+	Value  string
+	Line   int
+	Column int
+}
+
+func (t tscriptToken) String() string {
+	switch t.Type {
+	case tsEOF:
+		return "end of script"
+	case tsNewline:
+		return "end of line"
+	}
+	return fmt.Sprintf("'%s'", t.Value)
+}
+
+// tscriptError is a syntax error in a tscript, Line and Column start at 1
+type tscriptError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *tscriptError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+var tscriptKeywords = map[string]bool{"if": true, "then": true, "elif": true, "else": true, "end": true, "while": true, "do": true, "begin": true, "goto": true, "not": true, "and": true, "or": true}
+
+var tscriptOperators = []string{"==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "^", "&", "|", "=", "(", ")", "[", "]", "{", "}", ",", ":", "."}
+
+func tscriptTokenize(script string) ([]tscriptToken, error) {
+	result := []tscriptToken{}
+	runes := []rune(script)
+	line := 1
+	column := 1
+	depth := 0 // newlines inside (), [] and {} are ignored
+
+	advance := func(count int) {
+		for i := 0; i < count; i++ {
+			if runes[0] == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+			runes = runes[1:]
+		}
+	}
+
+	for len(runes) > 0 {
+		c := runes[0]
+		startLine, startColumn := line, column
+
+		switch {
+		case c == '\n':
+			if depth == 0 {
+				result = append(result, tscriptToken{tsNewline, "\n", startLine, startColumn})
+			}
+			advance(1)
+
+		case unicode.IsSpace(c):
+			advance(1)
+
+		case c == '#':
+			for len(runes) > 0 && runes[0] != '\n' {
+				advance(1)
+			}
+
+		case c == '"' || c == '\'':
+			i := 1
+			for i < len(runes) && runes[i] != c {
+				i++
+			}
+			if i == len(runes) {
+				return nil, &tscriptError{startLine, startColumn, "unterminated string"}
+			}
+			result = append(result, tscriptToken{tsString, string(runes[1:i]), startLine, startColumn})
+			advance(i + 1)
+
+		case unicode.IsDigit(c):
+			i := 0
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			if i+1 < len(runes) && runes[i] == '.' && unicode.IsDigit(runes[i+1]) {
+				i++
+				for i < len(runes) && unicode.IsDigit(runes[i]) {
+					i++
+				}
+			} else {
+				for groups := 0; groups < 2 && i+1 < len(runes) && runes[i] == ':' && unicode.IsDigit(runes[i+1]); groups++ { // time values, ie: 1:30 or 1:00:00
+					i++
+					for i < len(runes) && unicode.IsDigit(runes[i]) {
+						i++
+					}
+				}
+			}
+			if i < len(runes) && (unicode.IsLetter(runes[i]) || runes[i] == '_') {
+				return nil, &tscriptError{startLine, startColumn, fmt.Sprintf("invalid number '%s'", string(runes[:i+1]))}
+			}
+			result = append(result, tscriptToken{tsNumber, string(runes[:i]), startLine, startColumn})
+			advance(i)
+
+		case unicode.IsLetter(c) || c == '_':
+			i := 0
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '-') {
+				i++
+			}
+			for runes[i-1] == '-' { // a trailing '-' is the operator, not part of the name
+				i--
+			}
+			result = append(result, tscriptToken{tsIdent, string(runes[:i]), startLine, startColumn})
+			advance(i)
+
+		default:
+			matched := ""
+			for _, op := range tscriptOperators {
+				if strings.HasPrefix(string(runes[:min(len(runes), len(op))]), op) {
+					matched = op
+					break
+				}
+			}
+			if matched == "" {
+				return nil, &tscriptError{startLine, startColumn, fmt.Sprintf("unexpected character '%c'", c)}
+			}
+			switch matched {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				if depth > 0 {
+					depth--
+				}
+			}
+			result = append(result, tscriptToken{tsPunct, matched, startLine, startColumn})
+			advance(len(matched))
+		}
+	}
+
+	result = append(result, tscriptToken{tsEOF, "", line, column})
+
+	return result, nil
+}
+
+type tscriptParser struct {
+	tokens []tscriptToken
+	pos    int
+}
+
+func (p *tscriptParser) peek() tscriptToken {
+	return p.tokens[p.pos]
+}
+
+func (p *tscriptParser) next() tscriptToken {
+	t := p.tokens[p.pos]
+	if t.Type != tsEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *tscriptParser) is(tokenType tscriptTokenType, value string) bool {
+	t := p.peek()
+	return t.Type == tokenType && t.Value == value
+}
+
+func (p *tscriptParser) errorf(t tscriptToken, format string, a ...interface{}) error {
+	return &tscriptError{t.Line, t.Column, fmt.Sprintf(format, a...)}
+}
+
+func (p *tscriptParser) expect(tokenType tscriptTokenType, value string) (tscriptToken, error) {
+	t := p.next()
+	if t.Type != tokenType || (value != "" && t.Value != value) {
+		want := fmt.Sprintf("'%s'", value)
+		if value == "" {
+			want = map[tscriptTokenType]string{tsIdent: "a name", tsNewline: "end of line"}[tokenType]
+		}
+		return t, p.errorf(t, "expected %s, got %s", want, t)
+	}
+	return t, nil
+}
+
+func (p *tscriptParser) expectLabel() error {
+	t, err := p.expect(tsIdent, "")
+	if err != nil {
+		return err
+	}
+	if tscriptKeywords[t.Value] {
+		return p.errorf(t, "expected a label, got keyword '%s'", t.Value)
+	}
+	return nil
+}
+
+// parseLines parses statements until one of the keywords in terminators, the terminator is not consumed
+func (p *tscriptParser) parseLines(opener tscriptToken, terminators ...string) error {
+	for {
+		t := p.peek()
+		switch {
+		case t.Type == tsNewline:
+			p.next()
+			continue
+
+		case t.Type == tsEOF:
+			if len(terminators) > 0 {
+				return p.errorf(opener, "'%s' is missing its '%s'", opener.Value, terminators[len(terminators)-1])
+			}
+			return nil
+
+		case t.Type == tsIdent:
+			for _, terminator := range terminators {
+				if t.Value == terminator {
+					return nil
+				}
+			}
+		}
+
+		if err := p.parseStatement(); err != nil {
+			return err
+		}
+
+		t = p.peek()
+		if t.Type != tsNewline && t.Type != tsEOF {
+			return p.errorf(t, "expected end of line, got %s", t)
+		}
+	}
+}
+
+func (p *tscriptParser) parseStatement() error {
+	t := p.peek()
+
+	if t.Type == tsPunct && t.Value == ":" {
+		p.next()
+		return p.expectLabel()
+	}
+
+	if t.Type == tsIdent {
+		switch t.Value {
+		case "goto":
+			p.next()
+			return p.expectLabel()
+
+		case "if":
+			p.next()
+			if err := p.parseExpression(); err != nil {
+				return err
+			}
+			if _, err := p.expect(tsIdent, "then"); err != nil {
+				return err
+			}
+			if err := p.parseLines(t, "elif", "else", "end"); err != nil {
+				return err
+			}
+			for p.is(tsIdent, "elif") {
+				p.next()
+				if err := p.parseExpression(); err != nil {
+					return err
+				}
+				if _, err := p.expect(tsIdent, "then"); err != nil {
+					return err
+				}
+				if err := p.parseLines(t, "elif", "else", "end"); err != nil {
+					return err
+				}
+			}
+			if p.is(tsIdent, "else") {
+				p.next()
+				if err := p.parseLines(t, "end"); err != nil {
+					return err
+				}
+			}
+			_, err := p.expect(tsIdent, "end")
+			return err
+
+		case "while":
+			p.next()
+			if err := p.parseExpression(); err != nil {
+				return err
+			}
+			if _, err := p.expect(tsIdent, "do"); err != nil {
+				return err
+			}
+			if err := p.parseLines(t, "end"); err != nil {
+				return err
+			}
+			_, err := p.expect(tsIdent, "end")
+			return err
+
+		case "begin":
+			p.next()
+			if _, err := p.expect(tsPunct, "("); err != nil {
+				return err
+			}
+			if err := p.parseParamaters(); err != nil {
+				return err
+			}
+			if err := p.parseLines(t, "end"); err != nil {
+				return err
+			}
+			_, err := p.expect(tsIdent, "end")
+			return err
+
+		case "then", "do", "end", "elif", "else":
+			return p.errorf(t, "unexpected '%s'", t.Value)
+		}
+	}
+
+	assignable, err := p.parseBinary(0)
+	if err != nil {
+		return err
+	}
+
+	if p.is(tsPunct, "=") {
+		eq := p.next()
+		if !assignable {
+			return p.errorf(eq, "can only assign to a variable or array item")
+		}
+		return p.parseExpression()
+	}
+
+	return nil
+}
+
+// the lower the index the lower the precedence
+var tscriptPrecedence = [][]string{{"or"}, {"and"}, {"==", "!=", "<=", ">=", "<", ">"}, {"|", "&"}, {"+", "-"}, {"*", "/", "%"}, {"^"}}
+
+func (p *tscriptParser) parseExpression() error {
+	_, err := p.parseBinary(0)
+	return err
+}
+
+// parseBinary returns true if the expression can be assigned to
+func (p *tscriptParser) parseBinary(level int) (bool, error) {
+	if level == len(tscriptPrecedence) {
+		return p.parseUnary()
+	}
+
+	assignable, err := p.parseBinary(level + 1)
+	if err != nil {
+		return false, err
+	}
+
+	for {
+		t := p.peek()
+		found := false
+		if t.Type == tsPunct || t.Type == tsIdent {
+			for _, op := range tscriptPrecedence[level] {
+				if t.Value == op {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			return assignable, nil
+		}
+		p.next()
+		if _, err := p.parseBinary(level + 1); err != nil {
+			return false, err
+		}
+		assignable = false
+	}
+}
+
+func (p *tscriptParser) parseUnary() (bool, error) {
+	if p.is(tsIdent, "not") || p.is(tsPunct, "-") || p.is(tsPunct, "+") {
+		p.next()
+		_, err := p.parseUnary()
+		return false, err
+	}
+	return p.parsePrimary()
+}
+
+func (p *tscriptParser) parsePrimary() (bool, error) {
+	t := p.next()
+
+	switch t.Type {
+	case tsNumber, tsString:
+		return false, nil
+
+	case tsPunct:
+		switch t.Value {
+		case "(":
+			if err := p.parseExpression(); err != nil {
+				return false, err
+			}
+			_, err := p.expect(tsPunct, ")")
+			return false, err
+
+		case "[":
+			if err := p.parseList("]", p.parseExpression); err != nil {
+				return false, err
+			}
+			return p.parseIndex(false)
+
+		case "{":
+			err := p.parseList("}", func() error {
+				k := p.next()
+				if k.Type != tsIdent && k.Type != tsString {
+					return p.errorf(k, "expected a map key, got %s", k)
+				}
+				if _, err := p.expect(tsPunct, ":"); err != nil {
+					return err
+				}
+				return p.parseExpression()
+			})
+			return false, err
+		}
+
+	case tsIdent:
+		if tscriptKeywords[t.Value] {
+			return false, p.errorf(t, "unexpected keyword '%s'", t.Value)
+		}
+
+		switch t.Value {
+		case "True", "False", "None":
+			return false, nil
+
+		case "exists":
+			if p.is(tsPunct, "(") {
+				p.next()
+				assignable, err := p.parseBinary(0)
+				if err != nil {
+					return false, err
+				}
+				if !assignable {
+					return false, p.errorf(t, "exists requires a variable or array item")
+				}
+				_, err = p.expect(tsPunct, ")")
+				return false, err
+			}
+		}
+
+		if p.is(tsPunct, ".") {
+			p.next()
+			if err := p.expectLabel(); err != nil {
+				return false, err
+			}
+		}
+
+		if p.is(tsPunct, "(") {
+			p.next()
+			if err := p.parseParamaters(); err != nil {
+				return false, err
+			}
+			return p.parseIndex(false)
+		}
+
+		return p.parseIndex(true)
+	}
+
+	return false, p.errorf(t, "unexpected %s", t)
+}
+
+func (p *tscriptParser) parseIndex(assignable bool) (bool, error) {
+	for p.is(tsPunct, "[") {
+		p.next()
+		if err := p.parseExpression(); err != nil {
+			return false, err
+		}
+		if _, err := p.expect(tsPunct, "]"); err != nil {
+			return false, err
+		}
+		assignable = true
+	}
+	return assignable, nil
+}
+
+// parseList parses comma seperated items until closer, the opener has allready been consumed
+func (p *tscriptParser) parseList(closer string, item func() error) error {
+	if p.is(tsPunct, closer) {
+		p.next()
+		return nil
+	}
+
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+		t := p.next()
+		if t.Type == tsPunct && t.Value == closer {
+			return nil
+		}
+		if t.Type != tsPunct || t.Value != "," {
+			return p.errorf(t, "expected ',' or '%s', got %s", closer, t)
+		}
+	}
+}
+
+// parseParamaters parses the name=value paramaters of a function or begin block, the '(' has allready been consumed
+func (p *tscriptParser) parseParamaters() error {
+	return p.parseList(")", func() error {
+		if err := p.expectLabel(); err != nil {
+			return err
+		}
+		if _, err := p.expect(tsPunct, "="); err != nil {
+			return err
+		}
+		return p.parseExpression()
+	})
+}
+
+// lintTScript checks the script for syntax errors, nil is returned if the script parses
+func lintTScript(script string) error {
+	tokens, err := tscriptTokenize(script)
+	if err != nil {
+		return err
+	}
+
+	p := &tscriptParser{tokens: tokens}
+	if err := p.parseLines(tscriptToken{}); err != nil {
+		return err
+	}
+
+	t := p.peek()
+	if t.Type != tsEOF {
+		return p.errorf(t, "unexpected %s", t)
+	}
+
+	return nil
+}
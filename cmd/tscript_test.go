@@ -0,0 +1,151 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"errors"
+	"testing"
+)
+
+// scripts in the form used by the blueprints of Contractor and its plugins
+var tscriptBlueprintScripts = map[string]string{
+	"manual-foundation-create": `# Manual Foundation Create
+pause( msg='Manually set up the foundation, then resume' )
+`,
+
+	"ipmi-create": `begin( description="IPMI Foundation Create" )
+  foundation.power_off()
+  delay( seconds=10 )
+  foundation.set_pxe( pxe='bootstrap' )
+  foundation.power_on()
+  foundation.wait_for_poweroff()
+
+  foundation.set_pxe( pxe='normal-boot' )
+end
+`,
+
+	"vcenter-vm-create": `begin( description="VM Creation" )
+  vm_spec = vcenter.vm_spec()
+  foundation.vcenter_uuid = vcenter.create( vm_spec=vm_spec )
+  foundation.vcenter_paths = vcenter.get_paths()
+  foundation.set_interface_macs( interface_list=vcenter.get_interface_map() )
+end
+`,
+
+	"linux-base-create": `# Linux Base Install
+begin( description="Install Linux" )
+  foundation.wait_for_poweroff()
+
+  if not exists( config.installer_pxe ) then
+    config.installer_pxe = 'linux-installer'
+  end
+
+  foundation.set_pxe( pxe=config.installer_pxe )
+  foundation.power_on()
+  delay( seconds=1:00 )
+
+  :wait_install
+  if foundation.power_state == 'off' then
+    goto install_done
+  elif foundation.power_state == 'unknown' then
+    pause( msg="Unable to get the power state" )
+  else
+    delay( seconds=30 )
+  end
+  goto wait_install
+
+  :install_done
+  foundation.set_pxe( pxe='normal-boot' )
+  foundation.power_on()
+end
+`,
+
+	"linux-base-destroy": `begin( description="Destroy" )
+  count = 0
+  while count < 3 and foundation.power_state != 'off' do
+    foundation.power_off()
+    delay( seconds=( 10 * ( count + 1 ) ) )
+    count = count + 1
+  end
+end
+`,
+
+	"docker-container-create": `begin( description="Container Create" )
+  container_spec = {
+    image: config.docker_image,
+    command: [ '/bin/sh', '-c', config.docker_command ],
+    'port_map': { },
+    environment_map: { 'HOSTNAME': config.hostname, "DOMAIN": config.domain_name }
+  }
+  container_spec.environment_map[ 'STRUCTURE_ID' ] = structure.id
+  foundation.docker_id = docker.create( container_spec=container_spec, timeout=0:10:00 )
+  docker.start_container()   # start it up
+end
+`,
+}
+
+func TestLintTScriptBlueprints(t *testing.T) {
+	for name, script := range tscriptBlueprintScripts {
+		t.Run(name, func(t *testing.T) {
+			if err := lintTScript(script); err != nil {
+				t.Errorf("lintTScript returned error: %s", err)
+			}
+		})
+	}
+}
+
+func TestLintTScriptErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		script  string
+		line    int
+		column  int
+		message string
+	}{
+		{"unterminated string", "pause( msg='hello )\n", 1, 12, "unterminated string"},
+		{"unexpected character", "a = 1\nb = a $ 2\n", 2, 7, "unexpected character '$'"},
+		{"invalid number", "delay( seconds=10s )\n", 1, 16, "invalid number '10s'"},
+		{"missing end", "begin( description='x' )\n  delay( seconds=1 )\n", 1, 1, "'begin' is missing its 'end'"},
+		{"missing if end", "\nif a then\n  b = 1\n", 2, 1, "'if' is missing its 'end'"},
+		{"missing then", "if a\n  b = 1\nend\n", 1, 5, "expected 'then', got end of line"},
+		{"missing do", "while a\nend\n", 1, 8, "expected 'do', got end of line"},
+		{"unexpected end", "a = 1\nend\n", 2, 1, "unexpected 'end'"},
+		{"assign to call", "foo() = 1\n", 1, 7, "can only assign to a variable or array item"},
+		{"positional paramater", "delay( 10 )\n", 1, 8, "expected a name, got '10'"},
+		{"keyword label", "goto end\n", 1, 6, "expected a label, got keyword 'end'"},
+		{"two statements", "a = 1 b = 2\n", 1, 7, "expected end of line, got 'b'"},
+		{"unclosed call", "foo( a=1\nb = 2\n", 2, 1, "expected ',' or ')', got 'b'"},
+		{"bad map key", "a = { 1: 2 }\n", 1, 7, "expected a map key, got '1'"},
+		{"exists on value", "if exists( 1 ) then\nend\n", 1, 4, "exists requires a variable or array item"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := lintTScript(c.script)
+			if err == nil {
+				t.Fatalf("lintTScript(%q) did not return an error", c.script)
+			}
+			var tsErr *tscriptError
+			if !errors.As(err, &tsErr) {
+				t.Fatalf("lintTScript(%q) returned %T, want *tscriptError", c.script, err)
+			}
+			if tsErr.Line != c.line || tsErr.Column != c.column || tsErr.Message != c.message {
+				t.Errorf("lintTScript(%q) = line %d, column %d: %s, want line %d, column %d: %s", c.script, tsErr.Line, tsErr.Column, tsErr.Message, c.line, c.column, c.message)
+			}
+		})
+	}
+}
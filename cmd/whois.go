@@ -0,0 +1,392 @@
+package cmd
+
+/*
+Copyright © 2020 Peter Howe <pnhowe@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	cinp "github.com/cinp/go"
+	"github.com/spf13/cobra"
+)
+
+type whoisResult struct {
+	cinp.BaseObject
+	Match      string `json:"match"`
+	Structure  string `json:"structure,omitempty"`
+	Hostname   string `json:"hostname,omitempty"`
+	State      string `json:"state,omitempty"`
+	Blueprint  string `json:"blueprint,omitempty"`
+	Foundation string `json:"foundation,omitempty"`
+	Type       string `json:"type,omitempty"`
+	Interface  string `json:"interface,omitempty"`
+	Mac        string `json:"mac,omitempty"`
+	Addresses  string `json:"addresses,omitempty"`
+	Job        string `json:"job,omitempty"`
+}
+
+// normalizeMac returns mac in the lower case colon separated form, "" if mac is not a mac
+func normalizeMac(mac string) string {
+	hw, err := net.ParseMAC(strings.TrimSpace(mac))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(hw.String())
+}
+
+// infoMapHasMac returns true if any string value in value is the mac
+func infoMapHasMac(value interface{}, mac string) bool {
+	switch v := value.(type) {
+	case string:
+		return normalizeMac(v) == mac
+	case map[string]interface{}:
+		for _, item := range v {
+			if infoMapHasMac(item, mac) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if infoMapHasMac(item, mac) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// whoisFillStructure fills in the details of the structure, and its foundation
+func whoisFillStructure(ctx context.Context, result *whoisResult, structureID int) error {
+	s, err := contractorClient.BuildingStructureGet(ctx, structureID)
+	if err != nil {
+		return err
+	}
+	result.Structure = strconv.Itoa(structureID)
+	result.Hostname = stringValue(s.Hostname)
+	result.State = stringValue(s.State)
+	result.Blueprint = extractID(stringValue(s.Blueprint))
+
+	addressList := []string{}
+	vchan, err := contractorClient.UtilitiesAddressList(ctx, "structure", map[string]interface{}{"structure": s.GetURI()})
+	if err != nil {
+		return err
+	}
+	for v := range vchan {
+		addressList = append(addressList, fmt.Sprintf("%s(%s)", stringValue(v.IPAddress), stringValue(v.InterfaceName)))
+	}
+	result.Addresses = strings.Join(addressList, ", ")
+
+	jobURI, err := s.CallGetJob(ctx)
+	if err != nil {
+		return err
+	}
+	if jobURI != "" {
+		if j, err := contractorClient.ForemanStructureJobGetURI(ctx, jobURI); err == nil {
+			result.Job = fmt.Sprintf("%s %s (%s)", extractID(jobURI), stringValue(j.ScriptName), stringValue(j.State))
+		}
+	}
+
+	if s.Foundation != nil && *s.Foundation != "" && result.Foundation == "" {
+		return whoisFillFoundation(ctx, result, extractID(*s.Foundation), false)
+	}
+
+	return nil
+}
+
+// whoisFillFoundation fills in the details of the foundation, and if withStructure its structure
+func whoisFillFoundation(ctx context.Context, result *whoisResult, locator string, withStructure bool) error {
+	f, err := contractorClient.BuildingFoundationGet(ctx, locator)
+	if err != nil {
+		return err
+	}
+	result.Foundation = locator
+	result.Type = stringValue(f.Type)
+	if result.State == "" {
+		result.State = stringValue(f.State)
+	}
+	if result.Blueprint == "" {
+		result.Blueprint = extractID(stringValue(f.Blueprint))
+	}
+
+	if result.Mac == "" {
+		macList := []string{}
+		vchan, err := contractorClient.UtilitiesRealNetworkInterfaceList(ctx, "foundation", map[string]interface{}{"foundation": f.GetURI()})
+		if err != nil {
+			return err
+		}
+		for v := range vchan {
+			if result.Interface != "" && stringValue(v.Name) == result.Interface {
+				result.Mac = stringValue(v.Mac)
+			}
+			macList = append(macList, fmt.Sprintf("%s(%s)", stringValue(v.Mac), stringValue(v.Name)))
+		}
+		if result.Mac == "" && result.Interface == "" {
+			result.Mac = strings.Join(macList, ", ")
+		}
+	}
+
+	if withStructure && f.Structure != nil && *f.Structure != "" {
+		structureID, err := strconv.Atoi(extractID(*f.Structure))
+		if err != nil {
+			return err
+		}
+		return whoisFillStructure(ctx, result, structureID)
+	}
+
+	if result.Job == "" {
+		jobURI, err := f.CallGetJob(ctx)
+		if err != nil {
+			return err
+		}
+		if jobURI != "" {
+			if j, err := contractorClient.ForemanFoundationJobGetURI(ctx, jobURI); err == nil {
+				result.Job = fmt.Sprintf("%s %s (%s)", extractID(jobURI), stringValue(j.ScriptName), stringValue(j.State))
+			}
+		}
+	}
+
+	return nil
+}
+
+func whoisIP(ctx context.Context, ip net.IP) ([]*whoisResult, error) {
+	resultList := []*whoisResult{}
+
+	bchan, err := contractorClient.UtilitiesAddressBlockList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for block := range bchan {
+		network, err := blockNetwork(block)
+		if err != nil {
+			continue
+		}
+		bigOffset, ok := ipOffset(network, ip)
+		if !ok {
+			continue
+		}
+		offset := int(bigOffset.Int64())
+		where := fmt.Sprintf("in AddressBlock %s(%s) at offset %d", extractID(block.GetURI()), stringValue(block.Name), offset)
+
+		found := false
+		if block.GatewayOffset != nil && *block.GatewayOffset == offset {
+			resultList = append(resultList, &whoisResult{Match: "Gateway " + where})
+			found = true
+		}
+
+		vchan, err := contractorClient.UtilitiesAddressList(ctx, "address_block", map[string]interface{}{"address_block": block.GetURI()})
+		if err != nil {
+			return nil, err
+		}
+		for v := range vchan {
+			if *v.Offset != offset {
+				continue
+			}
+			found = true
+			result := &whoisResult{Match: "Static Address " + where, Interface: stringValue(v.InterfaceName)}
+			resultList = append(resultList, result)
+			networkedID, err := strconv.Atoi(extractID(stringValue(v.Networked)))
+			if err != nil {
+				continue
+			}
+			if _, err := contractorClient.BuildingStructureGet(ctx, networkedID); err != nil {
+				if !isNotFound(err) {
+					return nil, err
+				}
+				// not a structure, ie: a complex
+				result.Structure = fmt.Sprintf("Networked %d", networkedID)
+				result.Hostname = networkedHostname(ctx, stringValue(v.Networked), map[string]string{})
+				continue
+			}
+			if err := whoisFillStructure(ctx, result, networkedID); err != nil {
+				return nil, err
+			}
+		}
+
+		vchan2, err := contractorClient.UtilitiesReservedAddressList(ctx, "address_block", map[string]interface{}{"address_block": block.GetURI()})
+		if err != nil {
+			return nil, err
+		}
+		for v := range vchan2 {
+			if *v.Offset == offset {
+				found = true
+				resultList = append(resultList, &whoisResult{Match: fmt.Sprintf("Reserved Address %s, reason: %s", where, stringValue(v.Reason))})
+			}
+		}
+
+		vchan3, err := contractorClient.UtilitiesDynamicAddressList(ctx, "address_block", map[string]interface{}{"address_block": block.GetURI()})
+		if err != nil {
+			return nil, err
+		}
+		for v := range vchan3 {
+			if *v.Offset == offset {
+				found = true
+				match := "Dynamic Address " + where
+				if v.Pxe != nil && *v.Pxe != "" {
+					match += ", pxe: " + extractID(*v.Pxe)
+				}
+				resultList = append(resultList, &whoisResult{Match: match})
+			}
+		}
+
+		if !found {
+			resultList = append(resultList, &whoisResult{Match: "Unallocated " + where})
+		}
+	}
+
+	return resultList, nil
+}
+
+func whoisMac(ctx context.Context, mac string) ([]*whoisResult, error) {
+	resultList := []*whoisResult{}
+	seen := map[string]bool{}
+
+	ichan, err := contractorClient.UtilitiesRealNetworkInterfaceList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for v := range ichan {
+		if normalizeMac(stringValue(v.Mac)) != mac {
+			continue
+		}
+		locator := extractID(stringValue(v.Foundation))
+		seen[locator] = true
+		result := &whoisResult{Match: fmt.Sprintf("Interface %s of Foundation %s", stringValue(v.Name), locator), Interface: stringValue(v.Name), Mac: stringValue(v.Mac)}
+		resultList = append(resultList, result)
+		if locator != "" {
+			if err := whoisFillFoundation(ctx, result, locator, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	cchan, err := contractorClient.SurveyCartographerList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for v := range cchan {
+		if v.InfoMap == nil || !infoMapHasMac(*v.InfoMap, mac) {
+			continue
+		}
+		locator := extractID(stringValue(v.Foundation))
+		if locator != "" && seen[locator] {
+			continue
+		}
+		result := &whoisResult{Match: fmt.Sprintf("Cartographer %s", stringValue(v.Identifier)), Mac: mac}
+		resultList = append(resultList, result)
+		if locator != "" {
+			if err := whoisFillFoundation(ctx, result, locator, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return resultList, nil
+}
+
+func whoisName(ctx context.Context, name string) ([]*whoisResult, error) {
+	resultList := []*whoisResult{}
+
+	schan, err := contractorClient.BuildingStructureList(ctx, "", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	for v := range schan {
+		if !strings.EqualFold(stringValue(v.Hostname), name) {
+			continue
+		}
+		result := &whoisResult{Match: "Structure Hostname"}
+		resultList = append(resultList, result)
+		if err := whoisFillStructure(ctx, result, *v.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := contractorClient.BuildingFoundationGet(ctx, name); err == nil {
+		result := &whoisResult{Match: "Foundation Locator"}
+		resultList = append(resultList, result)
+		if err := whoisFillFoundation(ctx, result, name, true); err != nil {
+			return nil, err
+		}
+	}
+
+	return resultList, nil
+}
+
+var whoisCmd = &cobra.Command{
+	Use:   "whois",
+	Short: "Find who owns an IP Address, MAC, Hostname or Foundation Locator",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("requires an IP Address, MAC, Hostname or Locator argument")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := args[0]
+
+		ctx := cmd.Context()
+
+		var resultList []*whoisResult
+		var err error
+		if ip := net.ParseIP(target); ip != nil {
+			resultList, err = whoisIP(ctx, ip)
+		} else if mac := normalizeMac(target); mac != "" {
+			resultList, err = whoisMac(ctx, mac)
+		} else {
+			resultList, err = whoisName(ctx, target)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(resultList) == 0 {
+			return fmt.Errorf("nothing found for '%s'", target)
+		}
+
+		if asJSON {
+			outputDetail(resultList, "")
+			return nil
+		}
+
+		for i, result := range resultList {
+			if i > 0 {
+				fmt.Println()
+			}
+			outputDetail(result, `Match:       {{.Match}}
+{{if .Structure}}Structure:   {{.Structure}}
+Hostname:    {{.Hostname}}
+{{end}}{{if .Foundation}}Foundation:  {{.Foundation}} ({{.Type}})
+{{end}}{{if .State}}State:       {{.State}}
+{{end}}{{if .Blueprint}}Blueprint:   {{.Blueprint}}
+{{end}}{{if .Interface}}Interface:   {{.Interface}}
+{{end}}{{if .Mac}}Mac:         {{.Mac}}
+{{end}}{{if .Addresses}}Addresses:   {{.Addresses}}
+{{end}}{{if .Job}}Job:         {{.Job}}
+{{end}}`)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(whoisCmd)
+}
@@ -12,6 +12,8 @@ require (
 	github.com/spf13/viper v1.18.2
 	github.com/stromland/cobra-prompt v0.5.0
 	github.com/t3kton/contractor_goclient v1.0.12
+	golang.org/x/term v0.19.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -36,7 +38,6 @@ require (
 	golang.org/x/text v0.14.0 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 replace github.com/spf13/viper => github.com/spf13/viper v1.12.0